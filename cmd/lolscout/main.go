@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	env "github.com/Netflix/go-env"
@@ -12,8 +15,10 @@ import (
 	lolApi "github.com/haydenheroux/lolscout/pkg/api/lol"
 	playvsApi "github.com/haydenheroux/lolscout/pkg/api/playvs"
 	riotApi "github.com/haydenheroux/lolscout/pkg/api/riot"
+	"github.com/haydenheroux/lolscout/pkg/api/transport"
 	"github.com/haydenheroux/lolscout/pkg/db"
 	"github.com/haydenheroux/lolscout/pkg/model"
+	"github.com/haydenheroux/lolscout/pkg/static"
 	"github.com/haydenheroux/lolscout/pkg/tui"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -23,10 +28,13 @@ import (
 type Environment struct {
 	DatabaseName string `env:"DB_NAME,required=true"`
 	RiotApiKey   string `env:"RIOT_API_KEY,required=true"`
+	StaticDir    string `env:"STATIC_DIR,default=static"`
 }
 
 var environment Environment
 
+var apiTransport = transport.Default()
+
 func main() {
 	_, err := env.UnmarshalFromEnviron(&environment)
 	if err != nil {
@@ -42,10 +50,65 @@ func main() {
 func createCLIApp() *cli.App {
 	app := &cli.App{
 		Name: "lolscout",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "region",
+				Usage: "Riot region, such as na, euw or oce; defaults to a player's saved region",
+			},
+			&cli.StringFlag{
+				Name:  "riot-base-url",
+				Usage: "Riot API base URL, with %s for the platform or route",
+				Value: transport.DefaultRiotBaseURL,
+			},
+			&cli.StringFlag{
+				Name:  "playvs-base-url",
+				Usage: "PlayVS API base URL",
+				Value: transport.DefaultPlayVSBaseURL,
+			},
+			&cli.StringFlag{
+				Name:  "data-dragon-base-url",
+				Usage: "Data Dragon base URL",
+				Value: transport.DefaultDataDragonBaseURL,
+			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "save API responses as fixtures in `DIR`",
+			},
+			&cli.StringFlag{
+				Name:  "replay",
+				Usage: "serve API responses from fixtures in `DIR` instead of the network",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if c.IsSet("record") && c.IsSet("replay") {
+				return errors.New("--record cannot be combined with --replay")
+			}
+
+			apiTransport.RiotBaseURL = c.String("riot-base-url")
+			apiTransport.PlayVSBaseURL = c.String("playvs-base-url")
+			apiTransport.DataDragonBaseURL = c.String("data-dragon-base-url")
+
+			if c.IsSet("record") {
+				apiTransport = apiTransport.Record(c.String("record"))
+			}
+
+			if c.IsSet("replay") {
+				apiTransport = apiTransport.Replay(c.String("replay"))
+			} else {
+				apiTransport = apiTransport.RateLimited()
+			}
+
+			return nil
+		},
 		Commands: []*cli.Command{
 			createLOLCommand(),
 			createPlayVSCommand(),
 			createAnalyzeCommand(),
+			createRecomputeCommand(),
+			createPoolCommand(),
+			createBuildsCommand(),
+			createMatchCommand(),
+			createStaticCommand(),
 		},
 	}
 	return app
@@ -57,8 +120,18 @@ func createLOLCommand() *cli.Command {
 		Usage: "League of Legends",
 		Subcommands: []*cli.Command{
 			{
-				Name:  "scan",
-				Usage: "scan matches",
+				Name:      "scan",
+				Usage:     "scan matches in a window, or use a shortcut subcommand",
+				ArgsUsage: "<riot id>",
+				Flags:     windowFlags(),
+				Action: func(c *cli.Context) error {
+					options, err := windowOptionsOf(c)
+					if err != nil {
+						return err
+					}
+
+					return scanLeagueOfLegendsMatchesRiotId(c.Args().First(), options)
+				},
 				Subcommands: []*cli.Command{
 					createLOLScanCommand("day", "scan the last day of matches", 1),
 					createLOLScanCommand("week", "scan the last week of matches", 7),
@@ -66,6 +139,45 @@ func createLOLCommand() *cli.Command {
 					createLOLScanCommand("year", "scan the last year of matches", 365),
 				},
 			},
+			{
+				Name:      "live",
+				Usage:     "scout everyone in a player's current game",
+				ArgsUsage: "<riot id>",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "queue",
+						Usage: "queue to scan players who have not been scanned in; may be repeated",
+					},
+					&cli.IntFlag{
+						Name:  "last",
+						Usage: "scan the last `N` matches of players who have not been scanned",
+						Value: 20,
+					},
+				},
+				Before: migrateBeforeReading,
+				Action: func(c *cli.Context) error {
+					options, err := scanOptionsOf(c, time.Time{})
+					if err != nil {
+						return err
+					}
+
+					options.Last = c.Int("last")
+
+					return scoutLiveGame(c.Args().First(), options)
+				},
+			},
+			{
+				Name:  "refresh-names",
+				Usage: "update every player's Riot ID, keeping the Riot IDs they used before",
+				Action: func(c *cli.Context) error {
+					region, err := regionFlag(c)
+					if err != nil {
+						return err
+					}
+
+					return refreshRiotIDs(region)
+				},
+			},
 		},
 	}
 }
@@ -74,8 +186,14 @@ func createLOLScanCommand(name, usage string, daysAgo int) *cli.Command {
 	return &cli.Command{
 		Name:  name,
 		Usage: usage,
+		Flags: scanFlags(),
 		Action: func(c *cli.Context) error {
-			return scanLeagueOfLegendsMatchesRiotId(c.Args().First(), time.Now().AddDate(0, 0, -daysAgo))
+			options, err := scanOptionsOf(c, time.Now().AddDate(0, 0, -daysAgo))
+			if err != nil {
+				return err
+			}
+
+			return scanLeagueOfLegendsMatchesRiotId(c.Args().First(), options)
 		},
 	}
 }
@@ -86,16 +204,10 @@ func createPlayVSCommand() *cli.Command {
 		Usage: "PlayVS",
 		Subcommands: []*cli.Command{
 			{
-				Name:  "analyze",
-				Usage: "analyze a team's players",
-				Flags: []cli.Flag{
-					&cli.StringSliceFlag{
-						Name: "position",
-					},
-					&cli.StringSliceFlag{
-						Name: "champion",
-					},
-				},
+				Name:   "analyze",
+				Usage:  "analyze a team's players",
+				Flags:  analyzeFlags(),
+				Before: migrateBeforeReading,
 				Action: func(c *cli.Context) error {
 					dbc, err := db.CreateClient(environment.DatabaseName)
 					if err != nil {
@@ -112,31 +224,31 @@ func createPlayVSCommand() *cli.Command {
 						return err
 					}
 
-					riotIds := make([]string, len(team.Players))
+					riotIds := make([]riotApi.RiotID, len(team.Players))
 
 					for i, player := range team.Players {
-						riotIds[i] = riotApi.Join(player.GameName, player.TagLine)
+						riotIds[i] = adapter.PlayerRiotID(&player)
 					}
 
-					positionStrs := c.StringSlice("position")
-
-					positions := make([]model.Position, len(positionStrs))
-
-					for i, positionStr := range positionStrs {
-						positions[i] = model.PositionFromString(positionStr)
+					options, err := analyzeOptionsOf(c)
+					if err != nil {
+						return err
 					}
 
-					championStrs := c.StringSlice("champion")
-
-					champions := make([]model.Champion, len(championStrs))
-
-					for i, championStr := range championStrs {
-						champions[i] = model.Champion(championStr)
+					return analyzePlayers(riotIds, options)
+				},
+			},
+			{
+				Name:      "games",
+				Usage:     "list matches in which the team's players played together",
+				ArgsUsage: "<team id>",
+				Before:    migrateBeforeReadingMatches,
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() != 1 {
+						return errors.New("incorrect arguments")
 					}
 
-					analyzePlayers(riotIds, positions, champions)
-
-					return nil
+					return viewTeamGames(c.Args().First())
 				},
 			},
 			{
@@ -158,7 +270,23 @@ func createPlayVSCommand() *cli.Command {
 					fmt.Printf("has %d players\n", len(team.Players))
 
 					for _, player := range team.Players {
-						fmt.Printf("%s\n", riotApi.Join(player.GameName, player.TagLine))
+						snapshots, err := latestRankSnapshots(player.PUUID)
+						if err != nil {
+							return err
+						}
+
+						var ranks []string
+
+						for _, snapshot := range snapshots {
+							ranks = append(ranks, fmt.Sprintf("%s: %s", rankedQueueNames[snapshot.QueueType], snapshot))
+						}
+
+						if len(ranks) == 0 {
+							fmt.Printf("%s\n", adapter.PlayerRiotID(&player))
+							continue
+						}
+
+						fmt.Printf("%s (%s)\n", adapter.PlayerRiotID(&player), strings.Join(ranks, ", "))
 					}
 
 					return nil
@@ -190,8 +318,18 @@ func createPlayVSCommand() *cli.Command {
 				},
 			},
 			{
-				Name:  "scan",
-				Usage: "scan matches for a team",
+				Name:      "scan",
+				Usage:     "scan matches for a team in a window, or use a shortcut subcommand",
+				ArgsUsage: "<team id>",
+				Flags:     windowFlags(),
+				Action: func(c *cli.Context) error {
+					options, err := windowOptionsOf(c)
+					if err != nil {
+						return err
+					}
+
+					return scanPlayVSTeam(c.Args().First(), options)
+				},
 				Subcommands: []*cli.Command{
 					createPlayVSScanCommand("day", "scan the last day of matches", 1),
 					createPlayVSScanCommand("week", "scan the last week of matches", 7),
@@ -207,252 +345,1732 @@ func createPlayVSScanCommand(name, usage string, daysAgo int) *cli.Command {
 	return &cli.Command{
 		Name:  name,
 		Usage: usage,
+		Flags: scanFlags(),
 		Action: func(c *cli.Context) error {
-			dbc, err := db.CreateClient(environment.DatabaseName)
-			if err != nil {
-				return err
-			}
-
-			teamId := c.Args().First()
-
-			if len(teamId) == 0 {
-				return errors.New("team id not specified")
-			}
-
-			team, err := dbc.GetTeamByID(teamId)
-
+			options, err := scanOptionsOf(c, time.Now().AddDate(0, 0, -daysAgo))
 			if err != nil {
 				return err
 			}
 
-			for _, player := range team.Players {
-				err := scanLeagueOfLegendsMatches(player.GameName, player.TagLine, time.Now().AddDate(0, 0, -daysAgo))
-
-				if err != nil {
-					return err
-				}
-			}
-
-			return nil
+			return scanPlayVSTeam(c.Args().First(), options)
 		},
 	}
 }
 
-func createAnalyzeCommand() *cli.Command {
-	return &cli.Command{
-		Name:  "analyze",
-		Usage: "Analyze player metrics",
-		Flags: []cli.Flag{
-			&cli.StringSliceFlag{
-				Name: "position",
-			},
-			&cli.StringSliceFlag{
-				Name: "champion",
-			},
-		},
-		Action: func(c *cli.Context) error {
-			positionStrs := c.StringSlice("position")
+func scanPlayVSTeam(teamId string, options scanOptions) error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
 
-			positions := make([]model.Position, len(positionStrs))
+	if len(teamId) == 0 {
+		return errors.New("team id not specified")
+	}
 
-			for i, positionStr := range positionStrs {
-				positions[i] = model.PositionFromString(positionStr)
-			}
+	team, err := dbc.GetTeamByID(teamId)
 
-			championStrs := c.StringSlice("champion")
+	if err != nil {
+		return err
+	}
 
-			champions := make([]model.Champion, len(championStrs))
+	// A failed scan leaves a cursor for that player, and the rest of the
+	// team is still scanned
+	var errs []error
 
-			for i, championStr := range championStrs {
-				champions[i] = model.Champion(championStr)
-			}
+	for _, player := range team.Players {
+		err := scanLeagueOfLegendsMatches(adapter.PlayerRiotID(&player), options)
 
-			return analyzePlayers(c.Args().Slice(), positions, champions)
-		},
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
+
+	return errors.Join(errs...)
 }
 
-func scanLeagueOfLegendsMatchesRiotId(riotId string, startTime time.Time) error {
-	gameName, tagLine, err := riotApi.Split(riotId)
+func detectTeamGames(teamId string) (int, error) {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return 0, err
+	}
 
+	team, err := dbc.GetTeamByID(teamId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return scanLeagueOfLegendsMatches(gameName, tagLine, startTime)
+	return dbc.DetectTeamGames(team)
 }
 
-func scanLeagueOfLegendsMatches(gameName, tagLine string, startTime time.Time) error {
-	riot := riotApi.CreateClient(environment.RiotApiKey)
-	lol := lolApi.CreateClient(environment.RiotApiKey)
-
-	account, err := riot.Get(gameName, tagLine).Account()
+func viewTeamGames(teamId string) error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
 	if err != nil {
 		return err
 	}
 
-	dbc, err := db.CreateClient(environment.DatabaseName)
+	team, err := dbc.GetTeamByID(teamId)
 	if err != nil {
 		return err
 	}
 
-	puuid := account.PUUID
-
-	var player *model.Player
-
-	p, err := dbc.GetPlayerByPUUID(puuid)
-	if err == nil {
-		player = p
-	} else if errors.Is(err, gorm.ErrRecordNotFound) {
-		player = &model.Player{
-			PUUID:         puuid,
-			GameName:      gameName,
-			TagLine:       tagLine,
-			TeamID:        nil,
-			PlayerMetrics: make([]model.MatchMetrics, 0),
-		}
-	} else {
+	// Matches saved before team games were detected are picked up here
+	if _, err := dbc.DetectTeamGames(team); err != nil {
 		return err
 	}
 
-	log.Infof("getting matches for %s", riotApi.Join(gameName, tagLine))
-
-	summoner, err := lol.SummonerByPUUID(puuid)
+	games, err := dbc.GetTeamGames(team.ID)
 	if err != nil {
 		return err
 	}
 
-	queues := []lolApi.QueueType{lolApi.Queue.Normal, lolApi.Queue.Ranked, lolApi.Queue.Clash}
+	names := make(map[string]string)
 
-	matches, err := lol.Get(summoner, queues).Since(startTime)
-	if err != nil {
-		return err
+	for _, player := range team.Players {
+		names[player.PUUID] = player.GameName
 	}
 
-	log.Infof("got %d matches", len(matches))
+	wins := 0
 
-	var matchMetrics []*model.MatchMetrics
+	for _, game := range games {
+		if game.Win {
+			wins++
+		}
+	}
 
-	for _, match := range matches {
-		metrics := adapter.MatchMetrics(match, summoner)
+	fmt.Printf("%s: %d team games, %d-%d\n", team.Name, len(games), wins, len(games)-wins)
 
-		matchMetrics = append(matchMetrics, metrics)
-	}
+	tui.ViewTeamGames("Match", games, names)
 
-	scanned := len(matchMetrics)
-	appended := player.AppendMatchMetrics(matchMetrics)
+	return nil
+}
 
-	log.Infof("saving %d matches (%d duplicates)", appended, scanned-appended)
+func createAnalyzeCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "analyze",
+		Usage:  "Analyze player metrics",
+		Flags:  analyzeFlags(),
+		Before: migrateBeforeReading,
+		Action: func(c *cli.Context) error {
+			options, err := analyzeOptionsOf(c)
+			if err != nil {
+				return err
+			}
 
-	err = dbc.CreateOrUpdatePlayer(player)
-	if err != nil {
-		return err
+			riotIds, err := riotIDsOf(c.Args().Slice())
+			if err != nil {
+				return err
+			}
+
+			return analyzePlayers(riotIds, options)
+		},
 	}
+}
 
-	return nil
+type analyzeOptions struct {
+	Positions []model.Position
+	Champions []model.Champion
+	// If empty, matches since the start of season 14 are analyzed
+	Patches []string
+	ByPatch bool
+	Mode    model.MatchType
 }
 
-func initializePlayVSTeams() error {
-	riot := riotApi.CreateClient(environment.RiotApiKey)
-	playvs := playvsApi.CreateClient()
+func analyzeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name: "position",
+		},
+		&cli.StringSliceFlag{
+			Name: "champion",
+		},
+		&cli.StringSliceFlag{
+			Name:  "patch",
+			Usage: "only analyze matches played on a patch, such as 14.1; may be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "by-patch",
+			Usage: "also break down each player's performance by patch",
+		},
+		&cli.StringFlag{
+			Name:  "mode",
+			Usage: "analyze matches of a mode: rift, aram, arena, urf, nexus-blitz, one-for-all, ultimate-spellbook or other",
+			Value: "rift",
+		},
+	}
+}
 
-	region := playvs.GetRegion(playvsApi.EasternRegion)
+func analyzeOptionsOf(c *cli.Context) (analyzeOptions, error) {
+	positionStrs := c.StringSlice("position")
 
-	dbc, err := db.CreateClient(environment.DatabaseName)
-	if err != nil {
-		return err
-	}
+	positions := make([]model.Position, len(positionStrs))
 
-	teams, err := region.Teams()
+	for i, positionStr := range positionStrs {
+		positions[i] = model.PositionFromString(positionStr)
+	}
 
+	champions, err := championsOf(c.StringSlice("champion"))
 	if err != nil {
-		return err
+		return analyzeOptions{}, err
 	}
 
-	for _, team := range teams {
-		displayNames, err := region.Get(team).DisplayNames()
-		if err != nil {
-			return err
-		}
+	mode, err := model.ParseMatchType(c.String("mode"))
+	if err != nil {
+		return analyzeOptions{}, err
+	}
 
-		var accounts []*riotApi.Account
+	var patches []string
 
-		for _, displayName := range displayNames {
-			gameName, tagLine, err := riotApi.Split(displayName)
+	for _, patch := range c.StringSlice("patch") {
+		patches = append(patches, model.PatchOf(strings.TrimSpace(patch)))
+	}
 
-			if err != nil {
-				log.Warnf("%v %s", err, displayName)
-				continue
-			}
+	return analyzeOptions{
+		Positions: positions,
+		Champions: champions,
+		Patches:   patches,
+		ByPatch:   c.Bool("by-patch"),
+		Mode:      mode,
+	}, nil
+}
 
-			account, err := riot.Get(gameName, tagLine).Account()
-			if err != nil {
-				log.Warnf("could not find riot id %s", displayName)
-				log.Warnf("reason: %v", err)
-				continue
-			}
+// filter keeps the metrics of matches of the chosen mode played on the chosen
+// patches.
+func (o analyzeOptions) filter(metrics []model.MatchMetrics) []model.MatchMetrics {
+	metrics = analytics.ForMatchType(metrics, o.Mode)
 
-			accounts = append(accounts, account)
-		}
+	if len(o.Patches) == 0 {
+		return sinceS14(metrics)
+	}
 
-		err = dbc.CreateOrUpdateTeam(adapter.Team(team.ID, team.Name, accounts))
+	var filtered []model.MatchMetrics
 
-		if err != nil {
-			return err
+	for _, m := range metrics {
+		if slices.Contains(o.Patches, m.Patch) {
+			filtered = append(filtered, m)
 		}
 	}
 
-	return nil
+	return filtered
+}
+
+func createPoolCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "pool",
+		Usage:     "view a player's champion pool by mastery and recent performance",
+		ArgsUsage: "<riot id>",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "show the `N` most mastered champions as well as those played recently",
+				Value: 10,
+			},
+		},
+		Before: migrateBeforeReading,
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("incorrect arguments")
+			}
+
+			return viewChampionPool(c.Args().First(), c.Int("top"))
+		},
+	}
 }
 
-func analyzePlayers(riotIds []string, positions []model.Position, champions []model.Champion) error {
+func viewChampionPool(s string, top int) error {
 	dbc, err := db.CreateClient(environment.DatabaseName)
 	if err != nil {
 		return err
 	}
 
-	xs := make(map[string][]model.MatchMetrics)
-
-	for _, riotId := range riotIds {
-		name, tag, err := riotApi.Split(riotId)
-		if err != nil {
-			return err
-		}
-
-		player, err := dbc.GetPlayerByNameTag(name, tag)
-		if err != nil {
-			return err
-		}
-
-		var s14Metrics []model.MatchMetrics
+	riotId, err := riotApi.ParseRiotID(s)
+	if err != nil {
+		return err
+	}
 
-		s14Start := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+	player, err := dbc.GetPlayerByRiotID(riotId)
+	if err != nil {
+		return err
+	}
 
-		for _, metrics := range player.PlayerMetrics {
-			if metrics.StartTime.After(s14Start) {
-				s14Metrics = append(s14Metrics, metrics)
-			}
-		}
+	masteries, err := dbc.GetChampionMasteries(player.PUUID)
+	if err != nil {
+		return err
+	}
 
-		xs[riotId] = s14Metrics
+	if len(masteries) == 0 {
+		log.Warnf("no champion mastery saved for %s; scan them to get it", riotId)
 	}
 
-	doPositions(positions, xs)
+	metrics := analytics.ForMatchType(player.PlayerMetrics, model.MatchTypeSummonersRift)
 
-	doChampions(champions, xs)
+	tui.ViewPool(adapter.PlayerRiotID(player).String(), analytics.Pool(masteries, metrics, top))
 
 	return nil
 }
 
-func doPositions(positions []model.Position, xs map[string][]model.MatchMetrics) error {
-	for _, position := range positions {
-		doPosition(position, xs)
-	}
-
-	return nil
-}
+func createBuildsCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "builds",
+		Usage:     "view a player's most common builds, rune pages and summoner spells on a champion",
+		ArgsUsage: "<riot id>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "champion",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "mode",
+				Usage: "view builds in a mode: rift, aram, arena, urf, nexus-blitz, one-for-all, ultimate-spellbook or other",
+				Value: "rift",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "show the `N` most common of each",
+				Value: 5,
+			},
+		},
+		Before: migrateBeforeReading,
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("incorrect arguments")
+			}
 
-func doPosition(position model.Position, xs map[string][]model.MatchMetrics) {
+			mode, err := model.ParseMatchType(c.String("mode"))
+			if err != nil {
+				return err
+			}
+
+			return viewBuilds(c.Args().First(), c.String("champion"), mode, c.Int("top"))
+		},
+	}
+}
+
+func viewBuilds(s, championStr string, mode model.MatchType, top int) error {
+	data, err := static.Load(environment.StaticDir)
+	if err != nil {
+		return err
+	}
+
+	champion, err := data.Champion(championStr)
+	if err != nil {
+		return err
+	}
+
+	riotId, err := riotApi.ParseRiotID(s)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	player, err := dbc.GetPlayerByRiotID(riotId)
+	if err != nil {
+		return err
+	}
+
+	var metrics []model.MatchMetrics
+
+	for _, m := range analytics.ForMatchType(player.PlayerMetrics, mode) {
+		if m.Champion == model.Champion(champion.ID) {
+			metrics = append(metrics, m)
+		}
+	}
+
+	if len(metrics) == 0 {
+		log.Warnf("no %s games of %s on %s saved", mode, adapter.PlayerRiotID(player), champion.Name)
+		return nil
+	}
+
+	fmt.Printf("%s on %s, %d games\n", adapter.PlayerRiotID(player), champion.Name, len(metrics))
+
+	tui.ViewChoices("Build", analytics.CommonChoices(metrics, func(m model.MatchMetrics) string {
+		var items []string
+
+		for _, id := range m.ItemIDs() {
+			if data.Completed(id) {
+				items = append(items, data.Item(id))
+			}
+		}
+
+		// Items are in inventory order, not the order they were bought in
+		sort.Strings(items)
+
+		return strings.Join(items, ", ")
+	}, top))
+
+	tui.ViewChoices("Runes", analytics.CommonChoices(metrics, func(m model.MatchMetrics) string {
+		if m.Keystone == 0 {
+			return ""
+		}
+
+		return fmt.Sprintf("%s (%s + %s)", data.Rune(m.Keystone), data.Rune(m.PrimaryTree), data.Rune(m.SecondaryTree))
+	}, top))
+
+	tui.ViewChoices("Summoner Spells", analytics.CommonChoices(metrics, func(m model.MatchMetrics) string {
+		if m.Spell1 == 0 {
+			return ""
+		}
+
+		// Which key a spell is on does not matter
+		spells := []string{data.SummonerSpell(m.Spell1), data.SummonerSpell(m.Spell2)}
+		sort.Strings(spells)
+
+		return strings.Join(spells, " + ")
+	}, top))
+
+	return nil
+}
+
+func createMatchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "match",
+		Usage:     "view everyone who played in a stored match",
+		ArgsUsage: "<match id>",
+		Before:    migrateBeforeReadingMatches,
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("incorrect arguments")
+			}
+
+			return viewMatch(c.Args().First())
+		},
+	}
+}
+
+func viewMatch(matchId string) error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	match, err := dbc.GetMatchByID(matchId)
+	if err != nil {
+		return err
+	}
+
+	// Bans are only known by champion key
+	championName := func(key int) string {
+		return fmt.Sprint(key)
+	}
+
+	if data, err := static.Load(environment.StaticDir); err == nil {
+		championName = func(key int) string {
+			if champion, ok := data.ChampionByKey(key); ok {
+				return champion.Name
+			}
+
+			return fmt.Sprint(key)
+		}
+	}
+
+	fmt.Printf("%s, %s, patch %s, %d minutes, played %s\n", lolApi.QueueType(match.QueueID), match.MatchType, match.Patch, int(match.DurationMinutes), match.StartTime.Format("2006-01-02 15:04"))
+
+	for _, side := range []struct {
+		name   string
+		teamId int
+	}{{"Blue Team", 100}, {"Red Team", 200}} {
+		var participants []model.Participant
+
+		for _, participant := range match.Participants {
+			if participant.TeamID == side.teamId {
+				participants = append(participants, participant)
+			}
+		}
+
+		var bans []string
+
+		for _, ban := range match.Bans {
+			if ban.TeamID == side.teamId {
+				bans = append(bans, championName(ban.ChampionID))
+			}
+		}
+
+		title := side.name
+
+		if match.WinningTeam == side.teamId {
+			title += " (Victory)"
+		}
+
+		tui.ViewMatchTeam(title, participants, bans)
+	}
+
+	return nil
+}
+
+func createStaticCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "static",
+		Usage: "Data Dragon static data, kept in STATIC_DIR",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "download",
+				Usage: "download static data for a patch",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "version",
+						Usage: "patch to download, such as 14.1.1; defaults to the latest",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					version := c.String("version")
+
+					if len(version) == 0 {
+						latest, err := static.LatestVersion(apiTransport)
+						if err != nil {
+							return err
+						}
+
+						version = latest
+					}
+
+					if err := static.Download(apiTransport, environment.StaticDir, version); err != nil {
+						return err
+					}
+
+					fmt.Printf("saved static data for %s to %s\n", version, environment.StaticDir)
+
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// championsOf checks champions given on the command line against the static
+// data and returns their match-v5 names. Without static data, they are
+// used as given.
+func championsOf(championStrs []string) ([]model.Champion, error) {
+	champions := make([]model.Champion, len(championStrs))
+
+	if len(championStrs) == 0 {
+		return champions, nil
+	}
+
+	data, err := static.Load(environment.StaticDir)
+	if err != nil {
+		log.Warnf("%v; champions are not checked", err)
+
+		for i, championStr := range championStrs {
+			champions[i] = model.Champion(championStr)
+		}
+
+		return champions, nil
+	}
+
+	for i, championStr := range championStrs {
+		champion, err := data.Champion(championStr)
+		if err != nil {
+			return nil, err
+		}
+
+		champions[i] = model.Champion(champion.ID)
+	}
+
+	return champions, nil
+}
+
+// championNames maps champion keys to match-v5 names using the static data,
+// or the fallback if there is none.
+func championNames(fallback func() (map[int]string, error)) (map[int]string, error) {
+	data, err := static.Load(environment.StaticDir)
+	if err != nil {
+		return fallback()
+	}
+
+	return data.ChampionNames(), nil
+}
+
+func createRecomputeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "recompute",
+		Usage: "recompute metrics from stored matches",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "team",
+				Usage: "recompute metrics for a team's players",
+			},
+			&cli.BoolFlag{
+				Name:  "baseline",
+				Usage: "first save baseline metrics for every participant of every stored match",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			dbc, err := db.CreateClient(environment.DatabaseName)
+			if err != nil {
+				return err
+			}
+
+			if err := migrateMatches(); err != nil {
+				return err
+			}
+
+			if c.Bool("baseline") {
+				if err := saveBaselineMatchMetrics(); err != nil {
+					return err
+				}
+			}
+
+			var metrics []model.MatchMetrics
+
+			if teamId := c.String("team"); len(teamId) > 0 {
+				team, err := dbc.GetTeamByID(teamId)
+				if err != nil {
+					return err
+				}
+
+				for _, p := range team.Players {
+					player, err := dbc.GetPlayerByPUUID(p.PUUID)
+					if err != nil {
+						return err
+					}
+
+					metrics = append(metrics, player.PlayerMetrics...)
+				}
+			} else if c.Args().Len() > 0 {
+				riotIds, err := riotIDsOf(c.Args().Slice())
+				if err != nil {
+					return err
+				}
+
+				for _, riotId := range riotIds {
+					player, err := dbc.GetPlayerByRiotID(riotId)
+					if err != nil {
+						return err
+					}
+
+					metrics = append(metrics, player.PlayerMetrics...)
+				}
+			} else {
+				metrics, err = dbc.GetAllMatchMetrics()
+				if err != nil {
+					return err
+				}
+			}
+
+			summary, err := recomputeMatchMetrics(metrics)
+			if err != nil {
+				return err
+			}
+
+			for _, line := range summary.lines() {
+				fmt.Println(line)
+			}
+
+			return nil
+		},
+	}
+}
+
+// saveBaselineMatchMetrics saves baseline metrics for the participants of
+// stored matches who have none, without any API calls.
+func saveBaselineMatchMetrics() error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	matchIds, err := dbc.GetMatchPayloadIDs()
+	if err != nil {
+		return err
+	}
+
+	saved := 0
+
+	for _, matchId := range matchIds {
+		match, err := dbc.GetMatch(matchId)
+		if err != nil {
+			return err
+		}
+
+		timeline, err := dbc.GetTimeline(matchId)
+		if err != nil {
+			return err
+		}
+
+		// Participants with metrics, such as the scanned player, are skipped
+		n, err := dbc.CreateBaselineMatchMetrics(adapter.BaselineMatchMetrics(match, timeline, ""))
+		if err != nil {
+			return err
+		}
+
+		saved += n
+	}
+
+	fmt.Printf("saved %d baseline rows from %d matches\n", saved, len(matchIds))
+
+	return nil
+}
+
+// migrateMatches saves the stored matches that were stored before matches
+// were saved with all of their participants.
+func migrateMatches() error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	matchIds, err := dbc.GetMatchPayloadIDsWithoutMatch()
+	if err != nil {
+		return err
+	}
+
+	if len(matchIds) == 0 {
+		return nil
+	}
+
+	log.Infof("saving participants of %d stored matches", len(matchIds))
+
+	for _, matchId := range matchIds {
+		payload, err := dbc.GetMatchPayload(matchId)
+		if err != nil {
+			return err
+		}
+
+		match, err := dbc.GetMatch(matchId)
+		if err != nil {
+			return err
+		}
+
+		riotIds, err := lolApi.RiotIDs(payload)
+		if err != nil {
+			return err
+		}
+
+		if err := dbc.CreateOrUpdateMatch(adapter.Match(match, riotIds)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateBeforeReading runs before commands that read metrics, so that they
+// read metrics derived by the current version.
+func migrateBeforeReading(c *cli.Context) error {
+	return migrateMatchMetrics()
+}
+
+// migrateBeforeReadingMatches runs before commands that read matches, so
+// that matches stored before they were saved with their participants are
+// read too.
+func migrateBeforeReadingMatches(c *cli.Context) error {
+	return migrateMatches()
+}
+
+// migrateMatchMetrics derives stored metrics again if they were derived
+// differently by an older version, as long as their match is stored.
+func migrateMatchMetrics() error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := dbc.GetOutdatedMatchMetrics(adapter.MetricsVersion)
+	if err != nil {
+		return err
+	}
+
+	if len(metrics) > 0 {
+		log.Infof("migrating %d rows of match metrics to version %d", len(metrics), adapter.MetricsVersion)
+
+		summary, err := recomputeMatchMetrics(metrics)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range summary.lines() {
+			log.Info(line)
+		}
+	}
+
+	// Metrics saved before they were versioned have no stored match to
+	// derive them from, and their damage is left out of analytics
+	unversioned, err := dbc.CountMatchMetricsBeforeVersion(1)
+	if err != nil {
+		return err
+	}
+
+	if unversioned > 0 {
+		log.Warnf("%d rows of match metrics predate versioning and have no stored match; their damage is left out of analytics", unversioned)
+	}
+
+	return nil
+}
+
+type recomputeSummary struct {
+	total       int
+	changed     int
+	missing     int
+	fieldCounts map[string]int
+}
+
+func (s recomputeSummary) lines() []string {
+	lines := []string{fmt.Sprintf("recomputed %d rows: %d changed, %d without a stored match", s.total, s.changed, s.missing)}
+
+	fields := make([]string, 0, len(s.fieldCounts))
+
+	for field := range s.fieldCounts {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		lines = append(lines, fmt.Sprintf("%s: %d rows", field, s.fieldCounts[field]))
+	}
+
+	return lines
+}
+
+func recomputeMatchMetrics(rows []model.MatchMetrics) (recomputeSummary, error) {
+	summary := recomputeSummary{fieldCounts: make(map[string]int)}
+
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, metrics := range rows {
+		summary.total++
+
+		match, err := dbc.GetMatch(metrics.MatchID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			summary.missing++
+			continue
+		} else if err != nil {
+			return summary, err
+		}
+
+		timeline, err := dbc.GetTimeline(metrics.MatchID)
+		if err != nil {
+			return summary, err
+		}
+
+		recomputed := adapter.MatchMetrics(match, timeline, metrics.PUUID)
+		if recomputed.MatchID != metrics.MatchID {
+			summary.missing++
+			continue
+		}
+
+		recomputed.Model = metrics.Model
+		recomputed.Baseline = metrics.Baseline
+
+		fields := metrics.Diff(*recomputed)
+
+		// Metrics that come out the same are still saved with the current
+		// version, so that they are not migrated again
+		if len(fields) == 0 && metrics.Version == recomputed.Version {
+			continue
+		}
+
+		if err := dbc.UpdateMatchMetrics(recomputed); err != nil {
+			return summary, err
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		summary.changed++
+
+		for _, field := range fields {
+			summary.fieldCounts[field]++
+		}
+	}
+
+	return summary, nil
+}
+
+// regionFlag returns the region given with --region, or an empty region if
+// none was given.
+func regionFlag(c *cli.Context) (riotApi.Region, error) {
+	if !c.IsSet("region") {
+		return "", nil
+	}
+
+	return riotApi.ParseRegion(c.String("region"))
+}
+
+// regionOf returns the region to use for a player. A region given on the
+// command line takes precedence over the player's saved region.
+func regionOf(region riotApi.Region, player *model.Player) riotApi.Region {
+	if len(region) > 0 {
+		return region
+	}
+
+	if saved, err := riotApi.ParseRegion(player.Region); err == nil {
+		return saved
+	}
+
+	return riotApi.DefaultRegion
+}
+
+type scanOptions struct {
+	Region    riotApi.Region
+	Queues    []lolApi.QueueType
+	StartTime time.Time
+	// Zero means the time of the scan
+	EndTime time.Time
+	// If positive, scan the most recent matches instead of a window
+	Last     int
+	Resume   bool
+	Baseline bool
+}
+
+func scanFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "queue",
+			Usage: "queue to scan, such as ranked, flex, aram or custom; may be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "continue a scan that stopped early instead of starting a new one; without a window, players with nothing to resume are skipped",
+		},
+		&cli.BoolFlag{
+			Name:  "baseline",
+			Usage: "also save metrics of every other participant as a baseline to compare against",
+		},
+	}
+}
+
+func windowFlags() []cli.Flag {
+	return append(scanFlags(),
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "scan matches played on or after a date (2006-01-02) or time (RFC 3339)",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "scan matches played on or before a date (2006-01-02) or time (RFC 3339)",
+		},
+		&cli.IntFlag{
+			Name:  "last",
+			Usage: "scan the last `N` matches regardless of when they were played",
+		},
+	)
+}
+
+func windowOptionsOf(c *cli.Context) (scanOptions, error) {
+	options, err := scanOptionsOf(c, time.Time{})
+	if err != nil {
+		return scanOptions{}, err
+	}
+
+	if c.IsSet("since") {
+		options.StartTime, err = parseTime(c.String("since"), false)
+		if err != nil {
+			return scanOptions{}, err
+		}
+	}
+
+	if c.IsSet("until") {
+		options.EndTime, err = parseTime(c.String("until"), true)
+		if err != nil {
+			return scanOptions{}, err
+		}
+	}
+
+	options.Last = c.Int("last")
+
+	if options.Last < 0 {
+		return scanOptions{}, errors.New("--last must be positive")
+	}
+
+	if options.Last > 0 && (c.IsSet("since") || c.IsSet("until")) {
+		return scanOptions{}, errors.New("--last cannot be combined with --since or --until")
+	}
+
+	if options.Last == 0 && !c.IsSet("since") && !c.IsSet("until") && !options.Resume {
+		return scanOptions{}, errors.New("specify --since, --until or --last, or scan the last day, week, month or year")
+	}
+
+	return options, nil
+}
+
+// parseTime accepts a date or an RFC 3339 time. A date at the end of a
+// window includes the whole day.
+func parseTime(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad date %s", s)
+	}
+
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return t, nil
+}
+
+func scanOptionsOf(c *cli.Context, startTime time.Time) (scanOptions, error) {
+	region, err := regionFlag(c)
+	if err != nil {
+		return scanOptions{}, err
+	}
+
+	queues := lolApi.DefaultQueues
+
+	if queueStrs := c.StringSlice("queue"); len(queueStrs) > 0 {
+		queues = make([]lolApi.QueueType, len(queueStrs))
+
+		for i, queueStr := range queueStrs {
+			queues[i], err = lolApi.ParseQueue(queueStr)
+			if err != nil {
+				return scanOptions{}, err
+			}
+		}
+	}
+
+	return scanOptions{
+		Region:    region,
+		Queues:    queues,
+		StartTime: startTime,
+		Resume:    c.Bool("resume"),
+		Baseline:  c.Bool("baseline"),
+	}, nil
+}
+
+func scanLeagueOfLegendsMatchesRiotId(s string, options scanOptions) error {
+	riotId, err := riotApi.ParseRiotID(s)
+	if err != nil {
+		return err
+	}
+
+	return scanLeagueOfLegendsMatches(riotId, options)
+}
+
+func scanLeagueOfLegendsMatches(riotId riotApi.RiotID, options scanOptions) error {
+	riot := riotApi.CreateClient(environment.RiotApiKey, regionOf(options.Region, &model.Player{}), apiTransport)
+
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	account, err := riot.Get(riotId).Account()
+	if errors.Is(err, riotApi.ErrNotFound) {
+		// The player may have renamed since they were saved
+		p, dbErr := dbc.GetPlayerByRiotID(riotId)
+		if dbErr != nil {
+			return err
+		}
+
+		account, err = riot.AccountByPUUID(p.PUUID)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("%s is now %s", riotId, account.RiotID())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	puuid := account.PUUID
+
+	var player *model.Player
+
+	p, err := dbc.GetPlayerByPUUID(puuid)
+	if err == nil {
+		player = p
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		player = &model.Player{
+			PUUID:         puuid,
+			GameName:      account.GameName,
+			TagLine:       account.TagLine,
+			TeamID:        nil,
+			PlayerMetrics: make([]model.MatchMetrics, 0),
+		}
+	} else {
+		return err
+	}
+
+	now := time.Now()
+
+	if !player.CreatedAt.IsZero() && adapter.PlayerRiotID(player) != account.RiotID() {
+		if err := recordSavedRiotID(player); err != nil {
+			return err
+		}
+	}
+
+	player.GameName, player.TagLine = account.GameName, account.TagLine
+	player.Region = regionOf(options.Region, player).String()
+
+	if err := dbc.RecordRiotID(adapter.RiotID(puuid, account.RiotID(), now, now)); err != nil {
+		return err
+	}
+
+	lol := lolApi.CreateClient(environment.RiotApiKey, riotApi.Region(player.Region), apiTransport)
+
+	log.Infof("getting matches for %s (%s)", riotId, player.Region)
+
+	summoner, err := lol.SummonerByPUUID(puuid)
+	if err != nil {
+		return err
+	}
+
+	entries, err := lol.LeagueEntries(puuid)
+	if err != nil {
+		log.Warnf("could not get ranked standing of %s: %v", riotId, err)
+	} else {
+		for _, queueType := range lolApi.RankedQueues {
+			var entry *lolApi.LeagueEntry
+
+			for _, e := range entries {
+				if e.QueueType == queueType {
+					entry = e
+				}
+			}
+
+			if err := dbc.CreateRankSnapshot(adapter.RankSnapshot(puuid, queueType, entry)); err != nil {
+				return err
+			}
+		}
+	}
+
+	masteries, err := lol.ChampionMasteries(puuid)
+	if err != nil {
+		log.Warnf("could not get champion mastery of %s: %v", riotId, err)
+	} else if names, err := championNames(lol.ChampionNames); err != nil {
+		log.Warnf("could not get champion names: %v", err)
+	} else {
+		var championMasteries []*model.ChampionMastery
+
+		for _, mastery := range masteries {
+			championMasteries = append(championMasteries, adapter.ChampionMastery(puuid, mastery, names))
+		}
+
+		if err := dbc.CreateOrUpdateChampionMasteries(championMasteries); err != nil {
+			return err
+		}
+	}
+
+	startTime, endTime, queues, last := options.StartTime, options.EndTime, options.Queues, options.Last
+
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+
+	// A resumed scan of the most recent matches is of those before the
+	// scan that stopped, rather than of the newest matches
+	var lastBefore time.Time
+
+	if options.Resume {
+		cursor, err := dbc.GetScanCursor(puuid)
+		if err == nil {
+			startTime, endTime, last = cursor.StartTime, cursor.EndTime, cursor.Last
+
+			queues, err = splitQueues(cursor.Queues)
+			if err != nil {
+				return err
+			}
+
+			if last > 0 {
+				lastBefore = endTime
+
+				log.Infof("resuming scan of the last %d matches before %s", last, lastBefore.Format(time.DateTime))
+			} else {
+				log.Infof("resuming scan from %s to %s", startTime.Format(time.DateTime), endTime.Format(time.DateTime))
+			}
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Without a window of its own, the scan would cover the whole
+			// match history
+			if startTime.IsZero() && options.EndTime.IsZero() && last == 0 {
+				log.Infof("no scan to resume for %s, skipping", riotId)
+				return nil
+			}
+
+			log.Infof("no scan to resume for %s", riotId)
+		} else {
+			return err
+		}
+	}
+
+	// A failed listing still returns the IDs listed so far, which are
+	// fetched and saved before the failure is reported
+	var matchIds []string
+	var listErr error
+
+	if last > 0 {
+		matchIds, listErr = lol.Get(summoner, queues).LastMatchIDs(last, lastBefore)
+	} else {
+		matchIds, listErr = lol.Get(summoner, queues).MatchIDsBetween(startTime, endTime)
+	}
+
+	savedMatchIds, err := dbc.GetMatchIDsForPUUID(puuid)
+	if err != nil {
+		return err
+	}
+
+	newMatchIds := exclude(matchIds, savedMatchIds)
+
+	// Matches already downloaded while scanning another player, such as
+	// teammates, are read back from storage instead
+	storedMatchIds, err := dbc.GetStoredMatchIDs(newMatchIds)
+	if err != nil {
+		return err
+	}
+
+	downloadMatchIds := exclude(newMatchIds, storedMatchIds)
+
+	log.Infof("found %d matches (skipping %d already saved, %d already downloaded)", len(matchIds), len(matchIds)-len(newMatchIds), len(storedMatchIds))
+
+	downloaded, fetchErr := lol.MatchPayloads(downloadMatchIds)
+
+	log.Infof("got %d matches", len(downloaded))
+
+	var matches []*lolApi.MatchPayload
+
+	for _, matchId := range storedMatchIds {
+		match, err := dbc.GetMatch(matchId)
+		if err != nil {
+			return err
+		}
+
+		timeline, err := dbc.GetTimeline(matchId)
+		if err != nil {
+			return err
+		}
+
+		matches = append(matches, &lolApi.MatchPayload{Match: match, Timeline: timeline})
+	}
+
+	for _, match := range downloaded {
+		err := dbc.CreateOrUpdateMatchPayload(match.Match.Metadata.MatchID, match.Payload, match.TimelinePayload)
+		if err != nil {
+			return err
+		}
+
+		riotIds, err := lolApi.RiotIDs(match.Payload)
+		if err != nil {
+			return err
+		}
+
+		if err := dbc.CreateOrUpdateMatch(adapter.Match(match.Match, riotIds)); err != nil {
+			return err
+		}
+
+		matches = append(matches, match)
+	}
+
+	var matchMetrics []*model.MatchMetrics
+	var baselineMetrics []*model.MatchMetrics
+
+	for _, match := range matches {
+		metrics := adapter.MatchMetrics(match.Match, match.Timeline, puuid)
+
+		matchMetrics = append(matchMetrics, metrics)
+
+		if options.Baseline {
+			baselineMetrics = append(baselineMetrics, adapter.BaselineMatchMetrics(match.Match, match.Timeline, puuid)...)
+		}
+	}
+
+	scanned := len(matchMetrics)
+	appended := player.AppendMatchMetrics(matchMetrics)
+
+	log.Infof("saving %d matches (%d duplicates)", appended, scanned-appended)
+
+	err = dbc.CreateOrUpdatePlayer(player)
+	if err != nil {
+		return err
+	}
+
+	if err := dbc.ClaimBaselineMatchMetrics(puuid); err != nil {
+		return err
+	}
+
+	if options.Baseline {
+		saved, err := dbc.CreateBaselineMatchMetrics(baselineMetrics)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("saving %d baseline rows", saved)
+	}
+
+	if player.TeamID != nil {
+		found, err := detectTeamGames(*player.TeamID)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("found %d team games", found)
+	}
+
+	if scanErr := errors.Join(listErr, fetchErr); scanErr != nil {
+		cursor := &model.ScanCursor{
+			PUUID:     puuid,
+			StartTime: startTime,
+			EndTime:   endTime,
+			Queues:    joinQueues(queues),
+			Last:      last,
+			Reason:    scanErr.Error(),
+		}
+
+		if err := dbc.CreateOrUpdateScanCursor(cursor); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("scan of %s stopped early (rerun with --resume): %w", riotId, scanErr)
+	}
+
+	return dbc.DeleteScanCursor(puuid)
+}
+
+func joinQueues(queues []lolApi.QueueType) string {
+	ids := make([]string, len(queues))
+
+	for i, queue := range queues {
+		ids[i] = fmt.Sprint(int(queue))
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func splitQueues(s string) ([]lolApi.QueueType, error) {
+	var queues []lolApi.QueueType
+
+	for _, id := range strings.Split(s, ",") {
+		queue, err := lolApi.ParseQueue(id)
+		if err != nil {
+			return nil, err
+		}
+
+		queues = append(queues, queue)
+	}
+
+	return queues, nil
+}
+
+func exclude(xs []string, excluded []string) []string {
+	set := make(map[string]bool)
+
+	for _, x := range excluded {
+		set[x] = true
+	}
+
+	var result []string
+
+	for _, x := range xs {
+		if !set[x] {
+			result = append(result, x)
+		}
+	}
+
+	return result
+}
+
+// scoutLiveGame shows how everyone in a player's current game has played
+// their champion, scanning players who have not been scanned.
+func scoutLiveGame(s string, options scanOptions) error {
+	riotId, err := riotApi.ParseRiotID(s)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	riot := riotApi.CreateClient(environment.RiotApiKey, regionOf(options.Region, &model.Player{}), apiTransport)
+
+	account, err := riot.Get(riotId).Account()
+	if err != nil {
+		return err
+	}
+
+	player := &model.Player{}
+
+	if p, err := dbc.GetPlayerByPUUID(account.PUUID); err == nil {
+		player = p
+	}
+
+	// Everyone in the game plays on the same platform
+	options.Region = regionOf(options.Region, player)
+
+	lol := lolApi.CreateClient(environment.RiotApiKey, options.Region, apiTransport)
+
+	game, err := lol.ActiveGame(account.PUUID)
+	if err != nil {
+		return err
+	}
+
+	names, err := championNames(lol.ChampionNames)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s, %d minutes in\n", lolApi.QueueType(game.GameQueueConfigID), game.GameLength/60)
+
+	// Show how everyone has played their champion in the mode of the game
+	matchType := adapter.MatchType(game.GameMode, game.GameQueueConfigID, game.MapID)
+
+	teams := make(map[int][]tui.LiveParticipant)
+
+	for _, participant := range game.Participants {
+		live := tui.LiveParticipant{
+			RiotID:   participant.RiotID,
+			Champion: model.Champion(names[participant.ChampionID]),
+			Rank:     "-",
+		}
+
+		if !participant.Bot && len(participant.PUUID) > 0 {
+			p, err := dbc.GetPlayerByPUUID(participant.PUUID)
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				p, err = scanLiveParticipant(participant, options)
+			}
+
+			// A scan that stopped early still shows what it saved
+			if err != nil && p != nil {
+				log.Warnf("showing a partial scan of %s: %v", participant.RiotID, err)
+			} else if err != nil {
+				log.Warnf("could not scout %s: %v", participant.RiotID, err)
+			}
+
+			if p != nil {
+				rank, err := rankSummary(p.PUUID)
+				if err != nil {
+					return err
+				}
+
+				if rank != "" {
+					live.Rank = rank
+				}
+
+				var metrics []model.MatchMetrics
+
+				for _, m := range analytics.ForMatchType(p.PlayerMetrics, matchType) {
+					if m.Champion == live.Champion {
+						metrics = append(metrics, m)
+					}
+				}
+
+				if len(metrics) > 0 {
+					live.Analytics = analytics.Analyze(metrics)
+				}
+			}
+		}
+
+		teams[participant.TeamID] = append(teams[participant.TeamID], live)
+	}
+
+	tui.ViewLiveTeam("Blue Team", teams[100])
+	tui.ViewLiveTeam("Red Team", teams[200])
+
+	return nil
+}
+
+func scanLiveParticipant(participant *lolApi.CurrentGameParticipant, options scanOptions) (*model.Player, error) {
+	riotId, err := riotApi.ParseRiotID(participant.RiotID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The player and some of their matches may be saved even if the scan
+	// stops early, so they are returned with the error
+	scanErr := scanLeagueOfLegendsMatches(riotId, options)
+
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := dbc.GetPlayerByPUUID(participant.PUUID)
+	if err != nil {
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		return nil, err
+	}
+
+	return player, scanErr
+}
+
+func refreshRiotIDs(region riotApi.Region) error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	players, err := dbc.GetAllPlayers()
+	if err != nil {
+		return err
+	}
+
+	renamed, failed := 0, 0
+
+	for _, player := range players {
+		riot := riotApi.CreateClient(environment.RiotApiKey, regionOf(region, player), apiTransport)
+
+		account, err := riot.AccountByPUUID(player.PUUID)
+		if err != nil {
+			log.Warnf("could not refresh %s: %v", adapter.PlayerRiotID(player), err)
+			failed++
+			continue
+		}
+
+		now := time.Now()
+
+		if account.RiotID() == adapter.PlayerRiotID(player) {
+			if err := dbc.RecordRiotID(adapter.RiotID(player.PUUID, account.RiotID(), now, now)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := recordSavedRiotID(player); err != nil {
+			return err
+		}
+
+		if err := dbc.RecordRiotID(adapter.RiotID(player.PUUID, account.RiotID(), now, now)); err != nil {
+			return err
+		}
+
+		if err := dbc.RenamePlayer(player.PUUID, account.RiotID()); err != nil {
+			return err
+		}
+
+		log.Infof("%s is now %s", adapter.PlayerRiotID(player), account.RiotID())
+		renamed++
+	}
+
+	fmt.Printf("refreshed %d players: %d renamed, %d failed\n", len(players)-failed, renamed, failed)
+
+	return nil
+}
+
+// recordSavedRiotID keeps a player's saved Riot ID before it is replaced. A
+// player saved before Riot IDs were kept has none, so theirs is assumed to
+// have been in use since they were saved.
+func recordSavedRiotID(player *model.Player) error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	riotIds, err := dbc.GetRiotIDs(player.PUUID)
+	if err != nil {
+		return err
+	}
+
+	firstSeen := player.UpdatedAt
+
+	if len(riotIds) == 0 {
+		firstSeen = player.CreatedAt
+	}
+
+	return dbc.RecordRiotID(adapter.RiotID(player.PUUID, adapter.PlayerRiotID(player), firstSeen, player.UpdatedAt))
+}
+
+func initializePlayVSTeams() error {
+	riot := riotApi.CreateClient(environment.RiotApiKey, riotApi.DefaultRegion, apiTransport)
+	playvs := playvsApi.CreateClient(apiTransport)
+
+	region := playvs.GetRegion(playvsApi.EasternRegion)
+
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	teams, err := region.Teams()
+
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		displayNames, err := region.Get(team).DisplayNames()
+		if err != nil {
+			return err
+		}
+
+		var accounts []*riotApi.Account
+
+		for _, displayName := range displayNames {
+			riotId, err := riotApi.ParseRiotID(displayName)
+			if err != nil {
+				log.Warn(err)
+				continue
+			}
+
+			account, err := riot.Get(riotId).Account()
+			if err != nil {
+				log.Warnf("could not find riot id %s", displayName)
+				log.Warnf("reason: %v", err)
+				continue
+			}
+
+			now := time.Now()
+
+			if err := dbc.RecordRiotID(adapter.RiotID(account.PUUID, account.RiotID(), now, now)); err != nil {
+				return err
+			}
+
+			accounts = append(accounts, account)
+		}
+
+		err = dbc.CreateOrUpdateTeam(adapter.Team(team.ID, team.Name, accounts))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var rankedQueueNames = map[string]string{
+	lolApi.RankedSolo: "Solo/Duo",
+	lolApi.RankedFlex: "Flex",
+}
+
+// latestRankSnapshots is a player's latest standing in each ranked queue
+// they have been scanned in.
+func latestRankSnapshots(puuid string) ([]*model.RankSnapshot, error) {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []*model.RankSnapshot
+
+	for _, queueType := range lolApi.RankedQueues {
+		snapshot, err := dbc.GetLatestRankSnapshot(puuid, queueType)
+		if err != nil {
+			return nil, err
+		}
+
+		if snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// rankSummary is a one line summary of a player's latest standings in the
+// ranked queues they are placed in, or empty if they have never been scanned.
+func rankSummary(puuid string) (string, error) {
+	snapshots, err := latestRankSnapshots(puuid)
+	if err != nil {
+		return "", err
+	}
+
+	if len(snapshots) == 0 {
+		return "", nil
+	}
+
+	var ranks []string
+
+	for _, snapshot := range snapshots {
+		if snapshot.Tier != "" {
+			ranks = append(ranks, fmt.Sprintf("%s %s", rankedQueueNames[snapshot.QueueType], snapshot.Rank()))
+		}
+	}
+
+	if len(ranks) == 0 {
+		return "Unranked", nil
+	}
+
+	return strings.Join(ranks, ", "), nil
+}
+
+var s14Start = time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+
+func sinceS14(metrics []model.MatchMetrics) []model.MatchMetrics {
+	var s14Metrics []model.MatchMetrics
+
+	for _, m := range metrics {
+		if m.StartTime.After(s14Start) {
+			s14Metrics = append(s14Metrics, m)
+		}
+	}
+
+	return s14Metrics
+}
+
+// riotIDsOf parses Riot IDs given on the command line.
+func riotIDsOf(strs []string) ([]riotApi.RiotID, error) {
+	riotIds := make([]riotApi.RiotID, len(strs))
+
+	for i, s := range strs {
+		riotId, err := riotApi.ParseRiotID(s)
+		if err != nil {
+			return nil, err
+		}
+
+		riotIds[i] = riotId
+	}
+
+	return riotIds, nil
+}
+
+func analyzePlayers(riotIds []riotApi.RiotID, options analyzeOptions) error {
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	xs := make(map[string][]model.MatchMetrics)
+
+	// Players being analyzed are left out of the baseline
+	var puuids []string
+
+	for _, riotId := range riotIds {
+		player, err := dbc.GetPlayerByRiotID(riotId)
+		if err != nil {
+			return err
+		}
+
+		puuids = append(puuids, player.PUUID)
+
+		rank, err := rankSummary(player.PUUID)
+		if err != nil {
+			return err
+		}
+
+		header := adapter.PlayerRiotID(player).String()
+
+		if len(rank) > 0 {
+			header = fmt.Sprintf("%s (%s)", header, rank)
+		}
+
+		xs[header] = options.filter(player.PlayerMetrics)
+	}
+
+	if err := doPositions(options, xs, puuids); err != nil {
+		return err
+	}
+
+	return doChampions(options, xs, puuids)
+}
+
+func doPositions(options analyzeOptions, xs map[string][]model.MatchMetrics, puuids []string) error {
+	for _, position := range options.Positions {
+		if err := doPosition(position, options, xs, puuids); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func doPosition(position model.Position, options analyzeOptions, xs map[string][]model.MatchMetrics, puuids []string) error {
 	headers := []string{}
 
 	columns := []*analytics.AnalyticsSnapshot{}
@@ -468,18 +2086,40 @@ func doPosition(position model.Position, xs map[string][]model.MatchMetrics) {
 		columns = append(columns, analytics.Mean())
 	}
 
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	baseline, err := dbc.GetBaselineMetricsForPosition(position, puuids)
+	if err != nil {
+		return err
+	}
+
+	headers, columns = withBaseline(headers, columns, options.filter(baseline))
+
 	tui.ViewAnalytics(position.String(), headers, columns)
+
+	if options.ByPatch {
+		for riotId, metrics := range xs {
+			tui.ViewPatches(fmt.Sprintf("%s %s", riotId, position), analytics.AnalyzeByPatch(byPositionOf(metrics, position)))
+		}
+	}
+
+	return nil
 }
 
-func doChampions(champions []model.Champion, xs map[string][]model.MatchMetrics) error {
-	for _, champion := range champions {
-		doChampion(champion, xs)
+func doChampions(options analyzeOptions, xs map[string][]model.MatchMetrics, puuids []string) error {
+	for _, champion := range options.Champions {
+		if err := doChampion(champion, options, xs, puuids); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func doChampion(champion model.Champion, xs map[string][]model.MatchMetrics) {
+func doChampion(champion model.Champion, options analyzeOptions, xs map[string][]model.MatchMetrics, puuids []string) error {
 	headers := []string{}
 
 	columns := []*analytics.AnalyticsSnapshot{}
@@ -495,5 +2135,59 @@ func doChampion(champion model.Champion, xs map[string][]model.MatchMetrics) {
 		columns = append(columns, analytics.Mean())
 	}
 
+	dbc, err := db.CreateClient(environment.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	baseline, err := dbc.GetBaselineMetricsForChampion(champion, puuids)
+	if err != nil {
+		return err
+	}
+
+	headers, columns = withBaseline(headers, columns, options.filter(baseline))
+
 	tui.ViewAnalytics(champion.String(), headers, columns)
+
+	if options.ByPatch {
+		for riotId, metrics := range xs {
+			tui.ViewPatches(fmt.Sprintf("%s %s", riotId, champion), analytics.AnalyzeByPatch(byChampionOf(metrics, champion)))
+		}
+	}
+
+	return nil
+}
+
+func byPositionOf(metrics []model.MatchMetrics, position model.Position) []model.MatchMetrics {
+	var filtered []model.MatchMetrics
+
+	for _, m := range metrics {
+		if m.Position == position {
+			filtered = append(filtered, m)
+		}
+	}
+
+	return filtered
+}
+
+func byChampionOf(metrics []model.MatchMetrics, champion model.Champion) []model.MatchMetrics {
+	var filtered []model.MatchMetrics
+
+	for _, m := range metrics {
+		if m.Champion == champion {
+			filtered = append(filtered, m)
+		}
+	}
+
+	return filtered
+}
+
+// withBaseline adds a column of the baseline metrics of other players, for
+// the players' metrics to be compared against.
+func withBaseline(headers []string, columns []*analytics.AnalyticsSnapshot, baseline []model.MatchMetrics) ([]string, []*analytics.AnalyticsSnapshot) {
+	if len(columns) == 0 || len(baseline) == 0 {
+		return headers, columns
+	}
+
+	return append(headers, fmt.Sprintf("Baseline (%d games)", len(baseline))), append(columns, analytics.Analyze(baseline).Mean())
 }
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haydenheroux/lolscout/pkg/api/transport"
+	"github.com/haydenheroux/lolscout/pkg/db"
+)
+
+const replayTeamID = "t1"
+
+var (
+	replayStarters = []string{"p0", "p1", "p2"}
+	replayPlayers  = []string{"p0", "p1", "p2", "x3", "x4", "y0", "y1", "y2", "y3", "y4"}
+	replayMatches  = []string{"NA1_2", "NA1_1"}
+	replayPosition = []string{"TOP", "JUNGLE", "MIDDLE", "BOTTOM", "UTILITY"}
+)
+
+var (
+	accountByRiotID = regexp.MustCompile(`/riot/account/v1/accounts/by-riot-id/([^/]+)/([^/]+)$`)
+	accountByPUUID  = regexp.MustCompile(`/riot/account/v1/accounts/by-puuid/([^/]+)$`)
+	summonerByPUUID = regexp.MustCompile(`/lol/summoner/v4/summoners/by-puuid/([^/]+)$`)
+	matchIDs        = regexp.MustCompile(`/lol/match/v5/matches/by-puuid/[^/]+/ids$`)
+	matchTimeline   = regexp.MustCompile(`/lol/match/v5/matches/([^/]+)/timeline$`)
+	matchByID       = regexp.MustCompile(`/lol/match/v5/matches/([^/]+)$`)
+)
+
+func replayGameName(puuid string) string {
+	return strings.ToUpper(puuid) + "name"
+}
+
+func replayMatch(matchId string) map[string]any {
+	var number int
+	fmt.Sscanf(matchId, "NA1_%d", &number)
+
+	var participants []map[string]any
+
+	for i, puuid := range replayPlayers {
+		teamId := 100
+		if i >= 5 {
+			teamId = 200
+		}
+
+		participants = append(participants, map[string]any{
+			"puuid":                       puuid,
+			"participantId":               i + 1,
+			"teamId":                      teamId,
+			"riotIdGameName":              replayGameName(puuid),
+			"riotIdTagline":               "NA1",
+			"championName":                "Ahri",
+			"championId":                  103,
+			"teamPosition":                replayPosition[i%5],
+			"kills":                       i,
+			"deaths":                      1,
+			"assists":                     2,
+			"win":                         teamId == 100,
+			"totalDamageDealtToChampions": 1000 * (i + 1),
+			"totalMinionsKilled":          100,
+			"goldEarned":                  9000,
+			"champLevel":                  14,
+		})
+	}
+
+	return map[string]any{
+		"metadata": map[string]any{"matchId": matchId, "participants": replayPlayers},
+		"info": map[string]any{
+			"gameDuration":       1800,
+			"gameStartTimestamp": time.Now().Add(-time.Duration(number) * time.Hour).UnixMilli(),
+			"gameMode":           "CLASSIC",
+			"queueId":            420,
+			"mapId":              11,
+			"gameVersion":        "14.1.1",
+			"participants":       participants,
+			"teams": []map[string]any{
+				{"teamId": 100, "win": true, "bans": []any{}},
+				{"teamId": 200, "win": false, "bans": []any{}},
+			},
+		},
+	}
+}
+
+func replayRoster() map[string]any {
+	var starters []map[string]any
+
+	for _, puuid := range replayStarters {
+		starters = append(starters, map[string]any{
+			"player": map[string]any{
+				"user": map[string]any{
+					"userProviderAccounts": []map[string]any{
+						{"providerName": "Riot", "providerDisplayName": replayGameName(puuid) + "#NA1"},
+					},
+				},
+			},
+		})
+	}
+
+	return map[string]any{
+		"data": map[string]any{
+			"team": map[string]any{
+				"id":     replayTeamID,
+				"roster": map[string]any{"formats": []map[string]any{{"starters": starters}}},
+			},
+		},
+	}
+}
+
+// replayServer stands in for the Riot and PlayVS APIs.
+func replayServer(t *testing.T) *httptest.Server {
+	send := func(w http.ResponseWriter, status int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	notFound := map[string]any{"status": map[string]any{"status_code": http.StatusNotFound}}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graphql" {
+			var payload struct {
+				OperationName string `json:"operationName"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("bad PlayVS request: %v", err)
+			}
+
+			switch payload.OperationName {
+			case "getAllLeagueTeams":
+				send(w, http.StatusOK, map[string]any{
+					"data": map[string]any{
+						"getTeams": map[string]any{
+							"teams": []map[string]any{{"id": replayTeamID, "name": "Team One"}},
+						},
+					},
+				})
+			case "teamRoster":
+				send(w, http.StatusOK, replayRoster())
+			default:
+				send(w, http.StatusNotFound, notFound)
+			}
+
+			return
+		}
+
+		if m := accountByRiotID.FindStringSubmatch(r.URL.Path); m != nil {
+			puuid := strings.ToLower(strings.TrimSuffix(m[1], "name"))
+			send(w, http.StatusOK, map[string]any{"puuid": puuid, "gameName": m[1], "tagLine": m[2]})
+		} else if m := accountByPUUID.FindStringSubmatch(r.URL.Path); m != nil {
+			send(w, http.StatusOK, map[string]any{"puuid": m[1], "gameName": replayGameName(m[1]), "tagLine": "NA1"})
+		} else if m := summonerByPUUID.FindStringSubmatch(r.URL.Path); m != nil {
+			send(w, http.StatusOK, map[string]any{"puuid": m[1], "id": "s" + m[1], "summonerLevel": 30})
+		} else if strings.Contains(r.URL.Path, "/lol/league/v4/") {
+			send(w, http.StatusOK, []any{})
+		} else if matchIDs.MatchString(r.URL.Path) {
+			if r.URL.Query().Get("queue") == "420" {
+				send(w, http.StatusOK, replayMatches)
+			} else {
+				send(w, http.StatusOK, []string{})
+			}
+		} else if m := matchTimeline.FindStringSubmatch(r.URL.Path); m != nil {
+			send(w, http.StatusOK, map[string]any{
+				"metadata": map[string]any{"matchId": m[1]},
+				"info":     map[string]any{"frameInterval": 60000, "frames": []any{}},
+			})
+		} else if m := matchByID.FindStringSubmatch(r.URL.Path); m != nil {
+			send(w, http.StatusOK, replayMatch(m[1]))
+		} else {
+			// Includes champion mastery, so champion names are never
+			// downloaded from Data Dragon
+			send(w, http.StatusNotFound, notFound)
+		}
+	}))
+}
+
+type replayResult struct {
+	Teams     []string
+	Players   []string
+	MatchIDs  map[string][]string
+	TeamGames int
+	RiotIDs   map[string]string
+}
+
+// runReplayCommands runs init and a scan against a fresh database and
+// returns what they saved.
+func runReplayCommands(t *testing.T, databaseName string, flags ...string) replayResult {
+	environment = Environment{
+		DatabaseName: databaseName,
+		RiotApiKey:   "RGAPI-test",
+		StaticDir:    filepath.Join(t.TempDir(), "static"),
+	}
+
+	commands := [][]string{
+		{"playvs", "init"},
+		{"playvs", "scan", "week", replayTeamID},
+	}
+
+	for _, command := range commands {
+		// The transport is changed by every run of the app
+		apiTransport = transport.Default()
+
+		args := append(append([]string{"lolscout"}, flags...), command...)
+
+		if err := createCLIApp().Run(args); err != nil {
+			t.Fatalf("%s: %v", strings.Join(command, " "), err)
+		}
+	}
+
+	dbc, err := db.CreateClient(databaseName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := replayResult{MatchIDs: make(map[string][]string), RiotIDs: make(map[string]string)}
+
+	teams, err := dbc.GetAllTeams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, team := range teams {
+		result.Teams = append(result.Teams, team.ID+" "+team.Name)
+	}
+
+	team, err := dbc.GetTeamByID(replayTeamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, player := range team.Players {
+		result.Players = append(result.Players, player.PUUID)
+		result.RiotIDs[player.PUUID] = player.GameName + "#" + player.TagLine
+
+		matchIds, err := dbc.GetMatchIDsForPUUID(player.PUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slices.Sort(matchIds)
+		result.MatchIDs[player.PUUID] = matchIds
+	}
+
+	slices.Sort(result.Players)
+
+	games, err := dbc.GetTeamGames(replayTeamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result.TeamGames = len(games)
+
+	return result
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := filepath.Join(dir, "fixtures")
+
+	server := replayServer(t)
+
+	baseURLs := []string{
+		"--riot-base-url", server.URL + "/%s",
+		"--playvs-base-url", server.URL,
+		"--data-dragon-base-url", server.URL,
+	}
+
+	recorded := runReplayCommands(t, filepath.Join(dir, "record.db"), append(baseURLs, "--record", fixtures)...)
+
+	server.Close()
+
+	want := replayResult{
+		Teams:     []string{replayTeamID + " Team One"},
+		Players:   replayStarters,
+		MatchIDs:  map[string][]string{"p0": {"NA1_1", "NA1_2"}, "p1": {"NA1_1", "NA1_2"}, "p2": {"NA1_1", "NA1_2"}},
+		TeamGames: len(replayMatches),
+		RiotIDs:   map[string]string{"p0": "P0name#NA1", "p1": "P1name#NA1", "p2": "P2name#NA1"},
+	}
+
+	if !reflect.DeepEqual(recorded, want) {
+		t.Fatalf("recorded %+v, want %+v", recorded, want)
+	}
+
+	// Scans of the last week end now, so the replayed scan asks for a
+	// different window than the recorded one
+	time.Sleep(time.Second)
+
+	replayed := runReplayCommands(t, filepath.Join(dir, "replay.db"), append(baseURLs, "--replay", fixtures)...)
+
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+}
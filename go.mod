@@ -6,7 +6,10 @@ require (
 	github.com/KnutZuidema/golio v0.0.0-20231107153053-f8823dac1619
 	github.com/Netflix/go-env v0.0.0-20220526054621-78278af1949d
 	github.com/charmbracelet/lipgloss v0.9.1
+	github.com/montanaflynn/stats v0.7.1
 	github.com/sirupsen/logrus v1.9.3
+	gorm.io/driver/sqlite v1.5.5
+	gorm.io/gorm v1.25.7
 )
 
 require (
@@ -14,11 +17,8 @@ require (
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-sqlite3 v1.14.22 // indirect
-	github.com/montanaflynn/stats v0.7.1 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
-	gorm.io/driver/sqlite v1.5.5 // indirect
-	gorm.io/gorm v1.25.7 // indirect
 )
 
 require (
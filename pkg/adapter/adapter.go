@@ -1,9 +1,12 @@
 package adapter
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/KnutZuidema/golio/riot/lol"
+	lolApi "github.com/haydenheroux/lolscout/pkg/api/lol"
 	riot "github.com/haydenheroux/lolscout/pkg/api/riot"
 	"github.com/haydenheroux/lolscout/pkg/model"
 )
@@ -30,22 +33,156 @@ func Player(account *riot.Account) *model.Player {
 	}
 }
 
-func MatchMetrics(match *lol.Match, summoner *lol.Summoner) *model.MatchMetrics {
+// PlayerRiotID is the Riot ID a player was last saved with.
+func PlayerRiotID(player *model.Player) riot.RiotID {
+	return riot.RiotID{GameName: player.GameName, TagLine: player.TagLine}
+}
+
+// RiotID is a Riot ID in a player's history, seen between two times.
+func RiotID(puuid string, id riot.RiotID, firstSeen, lastSeen time.Time) *model.RiotID {
+	return &model.RiotID{
+		PUUID:     puuid,
+		GameName:  id.GameName,
+		TagLine:   id.TagLine,
+		FirstSeen: firstSeen,
+		LastSeen:  lastSeen,
+	}
+}
+
+// RankSnapshot is the standing of a player in a queue, or an unranked
+// standing if the entry is nil.
+func RankSnapshot(puuid, queueType string, entry *lolApi.LeagueEntry) *model.RankSnapshot {
+	snapshot := &model.RankSnapshot{
+		PUUID:     puuid,
+		QueueType: queueType,
+	}
+
+	if entry != nil {
+		snapshot.Tier = entry.Tier
+		snapshot.Division = entry.Rank
+		snapshot.LeaguePoints = entry.LeaguePoints
+		snapshot.Wins = entry.Wins
+		snapshot.Losses = entry.Losses
+	}
+
+	return snapshot
+}
+
+// ChampionMastery is a player's mastery of a champion, named using the given
+// champion names.
+func ChampionMastery(puuid string, mastery *lolApi.ChampionMastery, names map[int]string) *model.ChampionMastery {
+	return &model.ChampionMastery{
+		PUUID:        puuid,
+		ChampionID:   mastery.ChampionID,
+		Champion:     model.Champion(names[mastery.ChampionID]),
+		Level:        mastery.ChampionLevel,
+		Points:       mastery.ChampionPoints,
+		LastPlayTime: time.UnixMilli(mastery.LastPlayTime),
+	}
+}
+
+// Match is a match with all of its participants and bans, named with the
+// Riot IDs they played the match with.
+func Match(match *lol.Match, riotIds map[string]riot.RiotID) *model.Match {
+	m := &model.Match{
+		MatchID:         match.Metadata.MatchID,
+		StartTime:       time.UnixMilli(match.Info.GameStartTimestamp),
+		DurationMinutes: float64(match.Info.GameDuration) / 60.0,
+		QueueID:         match.Info.QueueID,
+		MatchType:       MatchType(match.Info.GameMode, match.Info.QueueID, match.Info.MapID),
+		Patch:           model.PatchOf(match.Info.GameVersion),
+	}
+
+	for _, participant := range match.Info.Participants {
+		riotId := riotIds[participant.PUUID]
+
+		m.Participants = append(m.Participants, model.Participant{
+			MatchID:       m.MatchID,
+			ParticipantID: participant.ParticipantID,
+			PUUID:         participant.PUUID,
+			GameName:      riotId.GameName,
+			TagLine:       riotId.TagLine,
+			TeamID:        participant.TeamID,
+			Champion:      model.Champion(participant.ChampionName),
+			ChampionID:    participant.ChampionID,
+			Position:      positionOf(participant),
+			Kills:         participant.Kills,
+			Deaths:        participant.Deaths,
+			Assists:       participant.Assists,
+			Win:           participant.Win,
+		})
+	}
+
+	for _, team := range match.Info.Teams {
+		if team.Win {
+			m.WinningTeam = team.TeamID
+		}
+
+		for _, ban := range team.Bans {
+			// Teams that do not ban use -1
+			if ban.ChampionID <= 0 {
+				continue
+			}
+
+			m.Bans = append(m.Bans, model.Ban{
+				MatchID:    m.MatchID,
+				TeamID:     team.TeamID,
+				PickTurn:   ban.PickTurn,
+				ChampionID: ban.ChampionID,
+			})
+		}
+	}
+
+	return m
+}
+
+// BaselineMatchMetrics derives the metrics of every participant other than
+// the player with the given PUUID.
+func BaselineMatchMetrics(match *lol.Match, timeline *lolApi.Timeline, puuid string) []*model.MatchMetrics {
+	var baseline []*model.MatchMetrics
+
+	for _, participant := range match.Info.Participants {
+		// Bots have no PUUID of their own
+		if participant.PUUID == puuid || participant.PUUID == "" || participant.PUUID == "BOT" {
+			continue
+		}
+
+		metrics := MatchMetrics(match, timeline, participant.PUUID)
+		metrics.Baseline = true
+
+		baseline = append(baseline, metrics)
+	}
+
+	return baseline
+}
+
+// MetricsVersion is the version of the derivation of match metrics. It is
+// bumped whenever metrics are derived differently, so that stored metrics are
+// derived again.
+const MetricsVersion = 4
+
+// MatchMetrics derives the metrics of the player with the given PUUID. The
+// timeline may be nil, in which case no timeline metrics are derived.
+func MatchMetrics(match *lol.Match, timeline *lolApi.Timeline, puuid string) *model.MatchMetrics {
 	teamDamage := make(map[int]int)
+	teamDamageTaken := make(map[int]int)
 	teamKills := make(map[int]int)
 
 	for _, participant := range match.Info.Participants {
-		teamDamage[participant.TeamID] += participant.TotalDamageDealt
+		teamDamage[participant.TeamID] += participant.TotalDamageDealtToChampions
+		teamDamageTaken[participant.TeamID] += participant.TotalDamageTaken
 		teamKills[participant.TeamID] += participant.Kills
 	}
 
 	durationMinutes := float64(match.Info.GameDuration) / 60.0
 
 	for _, participant := range match.Info.Participants {
-		if participant.PUUID == summoner.PUUID {
+		if participant.PUUID == puuid {
 			var metrics model.MatchMetrics
 
+			metrics.PUUID = puuid
 			metrics.MatchID = match.Metadata.MatchID
+			metrics.Version = MetricsVersion
 
 			metrics.StartTime = time.UnixMilli(match.Info.GameStartTimestamp)
 
@@ -55,21 +192,48 @@ func MatchMetrics(match *lol.Match, summoner *lol.Summoner) *model.MatchMetrics
 
 			metrics.Champion = model.Champion(participant.ChampionName)
 			metrics.ControlWardsPlaced = participant.DetectorWardsPlaced
-			metrics.DamageDealt = participant.TotalDamageDealt
+			metrics.DamageDealt = participant.TotalDamageDealtToChampions
 			metrics.DamageDealtPerMinute = float64(metrics.DamageDealt) / float64(durationMinutes)
-			metrics.DamageDealtShare = float64(metrics.DamageDealt) / float64(teamDamage[participant.TeamID])
+			metrics.DamageDealtShare = share(metrics.DamageDealt, teamDamage[participant.TeamID])
+			metrics.DamageMitigated = participant.DamageSelfMitigated
+			metrics.DamageMitigatedPerMinute = float64(metrics.DamageMitigated) / float64(durationMinutes)
+			metrics.DamageTaken = participant.TotalDamageTaken
+			metrics.DamageTakenPerMinute = float64(metrics.DamageTaken) / float64(durationMinutes)
+			metrics.DamageTakenShare = share(metrics.DamageTaken, teamDamageTaken[participant.TeamID])
 			metrics.Deaths = participant.Deaths
 			metrics.DurationMinutes = durationMinutes
-			metrics.KillParticipation = float64(participant.Kills+participant.Assists) / float64(teamKills[participant.TeamID])
+			metrics.KillParticipation = share(participant.Kills+participant.Assists, teamKills[participant.TeamID])
 			metrics.Kills = participant.Kills
 			metrics.Level = participant.ChampLevel
-			metrics.MatchType = matchTypeOf(match)
+			metrics.MagicDamageDealt = participant.MagicDamageDealtToChampions
+			metrics.MatchType = MatchType(match.Info.GameMode, match.Info.QueueID, match.Info.MapID)
+			metrics.Patch = model.PatchOf(match.Info.GameVersion)
+			metrics.PhysicalDamageDealt = participant.PhysicalDamageDealtToChampions
 			metrics.Position = positionOf(participant)
+			metrics.QueueID = match.Info.QueueID
+			metrics.TrueDamageDealt = participant.TrueDamageDealtToChampions
 			metrics.TurretsTaken = participant.TurretTakedowns
 			metrics.WardsKilled = participant.WardsKilled
 			metrics.WardsPlaced = participant.WardsPlaced
 			metrics.Win = participant.Win
 
+			loadout(&metrics, participant)
+
+			opponent := laneOpponentOf(match, participant)
+
+			if opponent != nil {
+				metrics.OpponentPUUID = opponent.PUUID
+				metrics.CSDiff = metrics.CS - (opponent.TotalMinionsKilled + opponent.NeutralMinionsKilled)
+				metrics.DamageDiff = metrics.DamageDealt - opponent.TotalDamageDealtToChampions
+				metrics.GoldDiff = participant.GoldEarned - opponent.GoldEarned
+				metrics.KillParticipationDiff = metrics.KillParticipation - share(opponent.Kills+opponent.Assists, teamKills[opponent.TeamID])
+				metrics.LevelDiff = metrics.Level - opponent.ChampLevel
+			}
+
+			if timeline != nil {
+				timelineMetrics(&metrics, timeline, participant, opponent)
+			}
+
 			return &metrics
 		}
 	}
@@ -77,9 +241,169 @@ func MatchMetrics(match *lol.Match, summoner *lol.Summoner) *model.MatchMetrics
 	return &model.MatchMetrics{}
 }
 
-// TODO
-func matchTypeOf(match *lol.Match) model.MatchType {
-	return model.MatchTypeSummonersRift
+// loadout sets the final items, runes and summoner spells of a participant.
+func loadout(metrics *model.MatchMetrics, participant *lol.Participant) {
+	items := []int{participant.Item0, participant.Item1, participant.Item2, participant.Item3, participant.Item4, participant.Item5}
+
+	ids := make([]string, len(items))
+
+	for i, item := range items {
+		ids[i] = fmt.Sprint(item)
+	}
+
+	metrics.Items = strings.Join(ids, ",")
+	metrics.Trinket = participant.Item6
+	metrics.Spell1 = participant.Summoner1ID
+	metrics.Spell2 = participant.Summoner2ID
+
+	if participant.Perks == nil {
+		return
+	}
+
+	for _, style := range participant.Perks.Styles {
+		switch style.Description {
+		case "primaryStyle":
+			metrics.PrimaryTree = style.Style
+
+			if len(style.Selections) > 0 {
+				metrics.Keystone = style.Selections[0].Perk
+			}
+		case "subStyle":
+			metrics.SecondaryTree = style.Style
+		}
+	}
+}
+
+// share is a part of a team's total, or zero if the team has none.
+func share(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(part) / float64(total)
+}
+
+func laneOpponentOf(match *lol.Match, participant *lol.Participant) *lol.Participant {
+	if participant.TeamPosition == "" {
+		return nil
+	}
+
+	for _, other := range match.Info.Participants {
+		if other.TeamID != participant.TeamID && other.TeamPosition == participant.TeamPosition {
+			return other
+		}
+	}
+
+	return nil
+}
+
+type frameMetrics struct {
+	cs   int
+	gold int
+	xp   int
+}
+
+func frameMetricsAt(timeline *lolApi.Timeline, minute int, participant *lol.Participant) (frameMetrics, bool) {
+	if participant == nil {
+		return frameMetrics{}, false
+	}
+
+	frame := timeline.Frame(minute, participant.ParticipantID)
+	if frame == nil {
+		return frameMetrics{}, false
+	}
+
+	return frameMetrics{
+		cs:   frame.MinionsKilled + frame.JungleMinionsKilled,
+		gold: frame.TotalGold,
+		xp:   frame.XP,
+	}, true
+}
+
+func timelineMetrics(metrics *model.MatchMetrics, timeline *lolApi.Timeline, participant, opponent *lol.Participant) {
+	frames := timeline.Info.Frames
+
+	if len(frames) == 0 {
+		return
+	}
+
+	metrics.TimelineMinutes = frames[len(frames)-1].Timestamp / 60000
+
+	if at10, ok := frameMetricsAt(timeline, 10, participant); ok {
+		metrics.CSAt10 = at10.cs
+		metrics.GoldAt10 = at10.gold
+		metrics.XPAt10 = at10.xp
+
+		if opponentAt10, ok := frameMetricsAt(timeline, 10, opponent); ok {
+			metrics.CSDiffAt10 = at10.cs - opponentAt10.cs
+			metrics.GoldDiffAt10 = at10.gold - opponentAt10.gold
+			metrics.XPDiffAt10 = at10.xp - opponentAt10.xp
+		}
+	}
+
+	if at15, ok := frameMetricsAt(timeline, 15, participant); ok {
+		metrics.CSAt15 = at15.cs
+		metrics.GoldAt15 = at15.gold
+		metrics.XPAt15 = at15.xp
+
+		if opponentAt15, ok := frameMetricsAt(timeline, 15, opponent); ok {
+			metrics.CSDiffAt15 = at15.cs - opponentAt15.cs
+			metrics.GoldDiffAt15 = at15.gold - opponentAt15.gold
+			metrics.XPDiffAt15 = at15.xp - opponentAt15.xp
+		}
+	}
+}
+
+var gameModes = map[string]model.MatchType{
+	"CLASSIC":    model.MatchTypeSummonersRift,
+	"SWIFTPLAY":  model.MatchTypeSummonersRift,
+	"ARAM":       model.MatchTypeARAM,
+	"CHERRY":     model.MatchTypeArena,
+	"URF":        model.MatchTypeURF,
+	"ARURF":      model.MatchTypeURF,
+	"NEXUSBLITZ": model.MatchTypeNexusBlitz,
+	"ONEFORALL":  model.MatchTypeOneForAll,
+	"ULTBOOK":    model.MatchTypeUltimateSpellbook,
+}
+
+// MatchType classifies a game by its game mode or, for games without one, by
+// its queue and then its map.
+func MatchType(gameMode string, queueId, mapId int) model.MatchType {
+	if len(gameMode) > 0 {
+		if matchType, ok := gameModes[gameMode]; ok {
+			return matchType
+		}
+
+		return model.MatchTypeOther
+	}
+
+	switch lolApi.QueueType(queueId) {
+	case lolApi.Queue.URF, lolApi.Queue.PickURF:
+		return model.MatchTypeURF
+	case lolApi.Queue.OneForAll:
+		return model.MatchTypeOneForAll
+	case lolApi.Queue.UltimateSpellbook:
+		return model.MatchTypeUltimateSpellbook
+	}
+
+	mapType := lolApi.QueueType(queueId).Map()
+
+	if mapType == lolApi.MapUnknown {
+		mapType = lolApi.MapType(mapId)
+	}
+
+	switch mapType {
+	case lolApi.MapSummonersRift:
+		return model.MatchTypeSummonersRift
+	case lolApi.MapHowlingAbyss:
+		return model.MatchTypeARAM
+	case lolApi.MapArena:
+		return model.MatchTypeArena
+	case lolApi.MapNexusBlitz:
+		return model.MatchTypeNexusBlitz
+	default:
+		return model.MatchTypeOther
+	}
 }
 
 func positionOf(participant *lol.Participant) model.Position {
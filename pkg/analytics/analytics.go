@@ -1,7 +1,10 @@
 package analytics
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/haydenheroux/lolscout/pkg/model"
 	"github.com/montanaflynn/stats"
@@ -12,14 +15,30 @@ type Norm struct {
 	StdDev float64
 }
 
+// emptyNorm is the norm of a metric with no data, such as damage taken when
+// every match was saved before it was measured.
+var emptyNorm = Norm{Mean: math.NaN(), StdDev: math.NaN()}
+
+func (n Norm) Empty() bool {
+	return math.IsNaN(n.Mean)
+}
+
 func (n Norm) String() string {
+	if n.Empty() {
+		return "-"
+	}
+
 	return fmt.Sprintf("N(μ: %.4f, σ: %.4f)", n.Mean, n.StdDev)
 }
 
 func calculateNorm(xs interface{}) Norm {
 	data := stats.LoadRawData(xs)
 
-	mean, _ := data.Mean()
+	mean, err := data.Mean()
+	if errors.Is(err, stats.EmptyInputErr) {
+		return emptyNorm
+	}
+
 	stdDev, _ := data.StandardDeviation()
 
 	return Norm{
@@ -29,54 +48,115 @@ func calculateNorm(xs interface{}) Norm {
 }
 
 type Analytics struct {
-	Assists              Norm
-	CSPerMinute          Norm
-	ControlWardsPlaced   Norm
-	DamageDealtPerMinute Norm
-	DamageDealtShare     Norm
-	Deaths               Norm
-	KillParticipation    Norm
-	Kills                Norm
-	Size                 int
-	TurretsTaken         Norm
-	WardsKilled          Norm
-	WardsPlaced          Norm
-	WinRate              float64
+	Assists                  Norm
+	CSAt10                   Norm
+	CSAt15                   Norm
+	CSDiff                   Norm
+	CSDiffAt10               Norm
+	CSDiffAt15               Norm
+	CSPerMinute              Norm
+	ControlWardsPlaced       Norm
+	DamageDealtPerMinute     Norm
+	DamageDealtShare         Norm
+	DamageDiff               Norm
+	DamageMitigatedPerMinute Norm
+	DamageTakenPerMinute     Norm
+	DamageTakenShare         Norm
+	Deaths                   Norm
+	GoldAt10                 Norm
+	GoldAt15                 Norm
+	GoldDiff                 Norm
+	GoldDiffAt10             Norm
+	GoldDiffAt15             Norm
+	KillParticipation        Norm
+	KillParticipationDiff    Norm
+	Kills                    Norm
+	LevelDiff                Norm
+	Size                     int
+	TurretsTaken             Norm
+	WardsKilled              Norm
+	WardsPlaced              Norm
+	WinRate                  float64
+	XPAt10                   Norm
+	XPAt15                   Norm
+	XPDiffAt10               Norm
+	XPDiffAt15               Norm
 }
 
 func (a Analytics) String() string {
 	var s string
 
 	s += fmt.Sprintln("Assists:", a.Assists)
+	s += fmt.Sprintln("CSAt10:", a.CSAt10)
+	s += fmt.Sprintln("CSAt15:", a.CSAt15)
+	s += fmt.Sprintln("CSDiff:", a.CSDiff)
+	s += fmt.Sprintln("CSDiffAt10:", a.CSDiffAt10)
+	s += fmt.Sprintln("CSDiffAt15:", a.CSDiffAt15)
 	s += fmt.Sprintln("CSPerMinute:", a.CSPerMinute)
 	s += fmt.Sprintln("ControlWardsPlaced:", a.ControlWardsPlaced)
 	s += fmt.Sprintln("DamageDealtPerMinute:", a.DamageDealtPerMinute)
 	s += fmt.Sprintln("DamageDealtShare:", a.DamageDealtShare)
+	s += fmt.Sprintln("DamageDiff:", a.DamageDiff)
+	s += fmt.Sprintln("DamageMitigatedPerMinute:", a.DamageMitigatedPerMinute)
+	s += fmt.Sprintln("DamageTakenPerMinute:", a.DamageTakenPerMinute)
+	s += fmt.Sprintln("DamageTakenShare:", a.DamageTakenShare)
 	s += fmt.Sprintln("Deaths:", a.Deaths)
+	s += fmt.Sprintln("GoldAt10:", a.GoldAt10)
+	s += fmt.Sprintln("GoldAt15:", a.GoldAt15)
+	s += fmt.Sprintln("GoldDiff:", a.GoldDiff)
+	s += fmt.Sprintln("GoldDiffAt10:", a.GoldDiffAt10)
+	s += fmt.Sprintln("GoldDiffAt15:", a.GoldDiffAt15)
 	s += fmt.Sprintln("KillParticipation:", a.KillParticipation)
+	s += fmt.Sprintln("KillParticipationDiff:", a.KillParticipationDiff)
 	s += fmt.Sprintln("Kills:", a.Kills)
+	s += fmt.Sprintln("LevelDiff:", a.LevelDiff)
 	s += fmt.Sprintln("Size:", a.Size)
 	s += fmt.Sprintln("TurretsTaken:", a.TurretsTaken)
 	s += fmt.Sprintln("WardsKilled:", a.WardsKilled)
 	s += fmt.Sprintln("WardsPlaced:", a.WardsPlaced)
 	s += fmt.Sprintf("WinRate: %.4f\n", a.WinRate)
+	s += fmt.Sprintln("XPAt10:", a.XPAt10)
+	s += fmt.Sprintln("XPAt15:", a.XPAt15)
+	s += fmt.Sprintln("XPDiffAt10:", a.XPDiffAt10)
+	s += fmt.Sprintln("XPDiffAt15:", a.XPDiffAt15)
 
 	return s
 }
 
+// AnalyticsSnapshot is a value of every metric. Metrics with no data are NaN.
 type AnalyticsSnapshot struct {
-	Assists              float64
-	CSPerMinute          float64
-	ControlWardsPlaced   float64
-	DamageDealtPerMinute float64
-	DamageDealtShare     float64
-	Deaths               float64
-	KillParticipation    float64
-	Kills                float64
-	TurretsTaken         float64
-	WardsKilled          float64
-	WardsPlaced          float64
-	WinRate              float64
+	Assists                  float64
+	CSAt10                   float64
+	CSAt15                   float64
+	CSDiff                   float64
+	CSDiffAt10               float64
+	CSDiffAt15               float64
+	CSPerMinute              float64
+	ControlWardsPlaced       float64
+	DamageDealtPerMinute     float64
+	DamageDealtShare         float64
+	DamageDiff               float64
+	DamageMitigatedPerMinute float64
+	DamageTakenPerMinute     float64
+	DamageTakenShare         float64
+	Deaths                   float64
+	GoldAt10                 float64
+	GoldAt15                 float64
+	GoldDiff                 float64
+	GoldDiffAt10             float64
+	GoldDiffAt15             float64
+	KillParticipation        float64
+	KillParticipationDiff    float64
+	Kills                    float64
+	LevelDiff                float64
+	TurretsTaken             float64
+	WardsKilled              float64
+	WardsPlaced              float64
+	WinRate                  float64
+	XPAt10                   float64
+	XPAt15                   float64
+	XPDiffAt10               float64
+	XPDiffAt15               float64
 }
 
 func (a Analytics) Mean() *AnalyticsSnapshot {
@@ -91,22 +171,46 @@ func (a Analytics) ZScore(z float64) *AnalyticsSnapshot {
 
 func (a Analytics) Percentile(percentile float64) *AnalyticsSnapshot {
 	percentileOf := func(n Norm) float64 {
+		if n.Empty() {
+			return math.NaN()
+		}
+
 		return stats.NormPpf(percentile, n.Mean, n.StdDev)
 	}
 
 	return &AnalyticsSnapshot{
-		Assists:              percentileOf(a.Assists),
-		CSPerMinute:          percentileOf(a.CSPerMinute),
-		ControlWardsPlaced:   percentileOf(a.ControlWardsPlaced),
-		DamageDealtPerMinute: percentileOf(a.DamageDealtPerMinute),
-		DamageDealtShare:     percentileOf(a.DamageDealtShare),
-		Deaths:               percentileOf(a.Deaths),
-		KillParticipation:    percentileOf(a.KillParticipation),
-		Kills:                percentileOf(a.Kills),
-		TurretsTaken:         percentileOf(a.TurretsTaken),
-		WardsKilled:          percentileOf(a.WardsKilled),
-		WardsPlaced:          percentileOf(a.WardsPlaced),
-		WinRate:              a.WinRate,
+		Assists:                  percentileOf(a.Assists),
+		CSAt10:                   percentileOf(a.CSAt10),
+		CSAt15:                   percentileOf(a.CSAt15),
+		CSDiff:                   percentileOf(a.CSDiff),
+		CSDiffAt10:               percentileOf(a.CSDiffAt10),
+		CSDiffAt15:               percentileOf(a.CSDiffAt15),
+		CSPerMinute:              percentileOf(a.CSPerMinute),
+		ControlWardsPlaced:       percentileOf(a.ControlWardsPlaced),
+		DamageDealtPerMinute:     percentileOf(a.DamageDealtPerMinute),
+		DamageDealtShare:         percentileOf(a.DamageDealtShare),
+		DamageDiff:               percentileOf(a.DamageDiff),
+		DamageMitigatedPerMinute: percentileOf(a.DamageMitigatedPerMinute),
+		DamageTakenPerMinute:     percentileOf(a.DamageTakenPerMinute),
+		DamageTakenShare:         percentileOf(a.DamageTakenShare),
+		Deaths:                   percentileOf(a.Deaths),
+		GoldAt10:                 percentileOf(a.GoldAt10),
+		GoldAt15:                 percentileOf(a.GoldAt15),
+		GoldDiff:                 percentileOf(a.GoldDiff),
+		GoldDiffAt10:             percentileOf(a.GoldDiffAt10),
+		GoldDiffAt15:             percentileOf(a.GoldDiffAt15),
+		KillParticipation:        percentileOf(a.KillParticipation),
+		KillParticipationDiff:    percentileOf(a.KillParticipationDiff),
+		Kills:                    percentileOf(a.Kills),
+		LevelDiff:                percentileOf(a.LevelDiff),
+		TurretsTaken:             percentileOf(a.TurretsTaken),
+		WardsKilled:              percentileOf(a.WardsKilled),
+		WardsPlaced:              percentileOf(a.WardsPlaced),
+		WinRate:                  a.WinRate,
+		XPAt10:                   percentileOf(a.XPAt10),
+		XPAt15:                   percentileOf(a.XPAt15),
+		XPDiffAt10:               percentileOf(a.XPDiffAt10),
+		XPDiffAt15:               percentileOf(a.XPDiffAt15),
 	}
 }
 
@@ -114,27 +218,60 @@ func (v AnalyticsSnapshot) String() string {
 	var s string
 
 	s += fmt.Sprintln("Assists:", v.Assists)
+	s += fmt.Sprintln("CSAt10:", v.CSAt10)
+	s += fmt.Sprintln("CSAt15:", v.CSAt15)
+	s += fmt.Sprintln("CSDiff:", v.CSDiff)
+	s += fmt.Sprintln("CSDiffAt10:", v.CSDiffAt10)
+	s += fmt.Sprintln("CSDiffAt15:", v.CSDiffAt15)
 	s += fmt.Sprintln("CSPerMinute:", v.CSPerMinute)
 	s += fmt.Sprintln("ControlWardsPlaced:", v.ControlWardsPlaced)
 	s += fmt.Sprintln("DamageDealtPerMinute:", v.DamageDealtPerMinute)
 	s += fmt.Sprintln("DamageDealtShare:", v.DamageDealtShare)
+	s += fmt.Sprintln("DamageDiff:", v.DamageDiff)
+	s += fmt.Sprintln("DamageMitigatedPerMinute:", v.DamageMitigatedPerMinute)
+	s += fmt.Sprintln("DamageTakenPerMinute:", v.DamageTakenPerMinute)
+	s += fmt.Sprintln("DamageTakenShare:", v.DamageTakenShare)
 	s += fmt.Sprintln("Deaths:", v.Deaths)
+	s += fmt.Sprintln("GoldAt10:", v.GoldAt10)
+	s += fmt.Sprintln("GoldAt15:", v.GoldAt15)
+	s += fmt.Sprintln("GoldDiff:", v.GoldDiff)
+	s += fmt.Sprintln("GoldDiffAt10:", v.GoldDiffAt10)
+	s += fmt.Sprintln("GoldDiffAt15:", v.GoldDiffAt15)
 	s += fmt.Sprintln("KillParticipation:", v.KillParticipation)
+	s += fmt.Sprintln("KillParticipationDiff:", v.KillParticipationDiff)
 	s += fmt.Sprintln("Kills:", v.Kills)
+	s += fmt.Sprintln("LevelDiff:", v.LevelDiff)
 	s += fmt.Sprintln("TurretsTaken:", v.TurretsTaken)
 	s += fmt.Sprintln("WardsKilled:", v.WardsKilled)
 	s += fmt.Sprintln("WardsPlaced:", v.WardsPlaced)
 	s += fmt.Sprintf("WinRate: %.4f\n", v.WinRate)
+	s += fmt.Sprintln("XPAt10:", v.XPAt10)
+	s += fmt.Sprintln("XPAt15:", v.XPAt15)
+	s += fmt.Sprintln("XPDiffAt10:", v.XPDiffAt10)
+	s += fmt.Sprintln("XPDiffAt15:", v.XPDiffAt15)
 
 	return s
 }
 
+// ForMatchType keeps the metrics of matches of a match type. Modes other than
+// Summoner's Rift play too differently to be analyzed together with it, so
+// metrics are analyzed by match type.
+func ForMatchType(metrics []model.MatchMetrics, matchType model.MatchType) []model.MatchMetrics {
+	var filtered []model.MatchMetrics
+
+	for _, metric := range metrics {
+		if metric.MatchType == matchType {
+			filtered = append(filtered, metric)
+		}
+	}
+
+	return filtered
+}
+
 func Analyze(metrics []model.MatchMetrics) *Analytics {
 	assists := make([]int, len(metrics))
 	csPerMinute := make([]float64, len(metrics))
 	controlWardsPlaced := make([]int, len(metrics))
-	damageDealtPerMinute := make([]float64, len(metrics))
-	damageDealtShare := make([]float64, len(metrics))
 	deaths := make([]int, len(metrics))
 	killParticipation := make([]float64, len(metrics))
 	kills := make([]int, len(metrics))
@@ -143,12 +280,24 @@ func Analyze(metrics []model.MatchMetrics) *Analytics {
 	wardsPlaced := make([]int, len(metrics))
 	wins := make([]bool, len(metrics))
 
+	// Timeline metrics only exist for matches whose timeline covers the minute
+	var csAt10, csAt15, csDiffAt10, csDiffAt15 []int
+	var goldAt10, goldAt15, goldDiffAt10, goldDiffAt15 []int
+	var xpAt10, xpAt15, xpDiffAt10, xpDiffAt15 []int
+
+	// Metrics saved before they were versioned count damage dealt to
+	// everything rather than to champions, and have no damage taken
+	var damageDealtPerMinute, damageDealtShare, damageMitigatedPerMinute []float64
+	var damageTakenPerMinute, damageTakenShare []float64
+
+	// Differentials only exist for matches with a lane opponent
+	var csDiff, damageDiff, goldDiff, levelDiff []int
+	var killParticipationDiff []float64
+
 	for i, metric := range metrics {
 		assists[i] = metric.Assists
 		csPerMinute[i] = metric.CSPerMinute
 		controlWardsPlaced[i] = metric.ControlWardsPlaced
-		damageDealtPerMinute[i] = metric.DamageDealtPerMinute
-		damageDealtShare[i] = metric.DamageDealtShare
 		deaths[i] = metric.Deaths
 		killParticipation[i] = metric.KillParticipation
 		kills[i] = metric.Kills
@@ -156,6 +305,46 @@ func Analyze(metrics []model.MatchMetrics) *Analytics {
 		wardsKilled[i] = metric.WardsKilled
 		wardsPlaced[i] = metric.WardsPlaced
 		wins[i] = metric.Win
+
+		if metric.Version > 0 {
+			damageDealtPerMinute = append(damageDealtPerMinute, metric.DamageDealtPerMinute)
+			damageDealtShare = append(damageDealtShare, metric.DamageDealtShare)
+			damageMitigatedPerMinute = append(damageMitigatedPerMinute, metric.DamageMitigatedPerMinute)
+			damageTakenPerMinute = append(damageTakenPerMinute, metric.DamageTakenPerMinute)
+			damageTakenShare = append(damageTakenShare, metric.DamageTakenShare)
+		}
+
+		if metric.OpponentPUUID != "" {
+			csDiff = append(csDiff, metric.CSDiff)
+			damageDiff = append(damageDiff, metric.DamageDiff)
+			goldDiff = append(goldDiff, metric.GoldDiff)
+			killParticipationDiff = append(killParticipationDiff, metric.KillParticipationDiff)
+			levelDiff = append(levelDiff, metric.LevelDiff)
+		}
+
+		if metric.TimelineMinutes >= 10 {
+			csAt10 = append(csAt10, metric.CSAt10)
+			goldAt10 = append(goldAt10, metric.GoldAt10)
+			xpAt10 = append(xpAt10, metric.XPAt10)
+
+			if metric.OpponentPUUID != "" {
+				csDiffAt10 = append(csDiffAt10, metric.CSDiffAt10)
+				goldDiffAt10 = append(goldDiffAt10, metric.GoldDiffAt10)
+				xpDiffAt10 = append(xpDiffAt10, metric.XPDiffAt10)
+			}
+		}
+
+		if metric.TimelineMinutes >= 15 {
+			csAt15 = append(csAt15, metric.CSAt15)
+			goldAt15 = append(goldAt15, metric.GoldAt15)
+			xpAt15 = append(xpAt15, metric.XPAt15)
+
+			if metric.OpponentPUUID != "" {
+				csDiffAt15 = append(csDiffAt15, metric.CSDiffAt15)
+				goldDiffAt15 = append(goldDiffAt15, metric.GoldDiffAt15)
+				xpDiffAt15 = append(xpDiffAt15, metric.XPDiffAt15)
+			}
+		}
 	}
 
 	assistsNorm := calculateNorm(assists)
@@ -163,6 +352,9 @@ func Analyze(metrics []model.MatchMetrics) *Analytics {
 	controlWardsPlacedNorm := calculateNorm(controlWardsPlaced)
 	damageDealtPerMinuteNorm := calculateNorm(damageDealtPerMinute)
 	damageDealtShareNorm := calculateNorm(damageDealtShare)
+	damageMitigatedPerMinuteNorm := calculateNorm(damageMitigatedPerMinute)
+	damageTakenPerMinuteNorm := calculateNorm(damageTakenPerMinute)
+	damageTakenShareNorm := calculateNorm(damageTakenShare)
 	deathsNorm := calculateNorm(deaths)
 	killParticipationNorm := calculateNorm(killParticipation)
 	killsNorm := calculateNorm(kills)
@@ -170,21 +362,58 @@ func Analyze(metrics []model.MatchMetrics) *Analytics {
 	wardsKilledNorm := calculateNorm(wardsKilled)
 	wardsPlacedNorm := calculateNorm(wardsPlaced)
 	winRate := percentTrue(wins)
+	csAt10Norm := calculateNorm(csAt10)
+	csAt15Norm := calculateNorm(csAt15)
+	csDiffAt10Norm := calculateNorm(csDiffAt10)
+	csDiffAt15Norm := calculateNorm(csDiffAt15)
+	goldAt10Norm := calculateNorm(goldAt10)
+	goldAt15Norm := calculateNorm(goldAt15)
+	goldDiffAt10Norm := calculateNorm(goldDiffAt10)
+	goldDiffAt15Norm := calculateNorm(goldDiffAt15)
+	xpAt10Norm := calculateNorm(xpAt10)
+	xpAt15Norm := calculateNorm(xpAt15)
+	xpDiffAt10Norm := calculateNorm(xpDiffAt10)
+	xpDiffAt15Norm := calculateNorm(xpDiffAt15)
+	csDiffNorm := calculateNorm(csDiff)
+	damageDiffNorm := calculateNorm(damageDiff)
+	goldDiffNorm := calculateNorm(goldDiff)
+	killParticipationDiffNorm := calculateNorm(killParticipationDiff)
+	levelDiffNorm := calculateNorm(levelDiff)
 
 	return &Analytics{
-		Assists:              assistsNorm,
-		CSPerMinute:          csPerMinuteNorm,
-		ControlWardsPlaced:   controlWardsPlacedNorm,
-		DamageDealtPerMinute: damageDealtPerMinuteNorm,
-		DamageDealtShare:     damageDealtShareNorm,
-		Deaths:               deathsNorm,
-		KillParticipation:    killParticipationNorm,
-		Kills:                killsNorm,
-		Size:                 len(metrics),
-		TurretsTaken:         turretsTakenNorm,
-		WardsKilled:          wardsKilledNorm,
-		WardsPlaced:          wardsPlacedNorm,
-		WinRate:              winRate,
+		Assists:                  assistsNorm,
+		CSAt10:                   csAt10Norm,
+		CSAt15:                   csAt15Norm,
+		CSDiff:                   csDiffNorm,
+		CSDiffAt10:               csDiffAt10Norm,
+		CSDiffAt15:               csDiffAt15Norm,
+		CSPerMinute:              csPerMinuteNorm,
+		ControlWardsPlaced:       controlWardsPlacedNorm,
+		DamageDealtPerMinute:     damageDealtPerMinuteNorm,
+		DamageDealtShare:         damageDealtShareNorm,
+		DamageDiff:               damageDiffNorm,
+		DamageMitigatedPerMinute: damageMitigatedPerMinuteNorm,
+		DamageTakenPerMinute:     damageTakenPerMinuteNorm,
+		DamageTakenShare:         damageTakenShareNorm,
+		Deaths:                   deathsNorm,
+		GoldAt10:                 goldAt10Norm,
+		GoldAt15:                 goldAt15Norm,
+		GoldDiff:                 goldDiffNorm,
+		GoldDiffAt10:             goldDiffAt10Norm,
+		GoldDiffAt15:             goldDiffAt15Norm,
+		KillParticipation:        killParticipationNorm,
+		KillParticipationDiff:    killParticipationDiffNorm,
+		Kills:                    killsNorm,
+		LevelDiff:                levelDiffNorm,
+		Size:                     len(metrics),
+		TurretsTaken:             turretsTakenNorm,
+		WardsKilled:              wardsKilledNorm,
+		WardsPlaced:              wardsPlacedNorm,
+		WinRate:                  winRate,
+		XPAt10:                   xpAt10Norm,
+		XPAt15:                   xpAt15Norm,
+		XPDiffAt10:               xpDiffAt10Norm,
+		XPDiffAt15:               xpDiffAt15Norm,
 	}
 }
 
@@ -249,6 +478,85 @@ func byChampion(metrics []model.MatchMetrics) championMetrics {
 	return championMetrics
 }
 
+// PoolEntry is a champion in a player's pool.
+type PoolEntry struct {
+	Champion model.Champion
+	// Nil if the player's mastery of the champion is unknown
+	Mastery *model.ChampionMastery
+	// Nil if the player has not played the champion in a stored match
+	Analytics *Analytics
+}
+
+// Pool merges a player's most mastered champions with every champion they
+// have played in the metrics, ordered by mastery points.
+func Pool(masteries []*model.ChampionMastery, metrics []model.MatchMetrics, top int) []*PoolEntry {
+	metricsByChampion := byChampion(metrics)
+
+	entries := make(map[model.Champion]*PoolEntry)
+
+	for champion, metrics := range metricsByChampion {
+		entries[champion] = &PoolEntry{
+			Champion:  champion,
+			Analytics: Analyze(metrics),
+		}
+	}
+
+	sort.Slice(masteries, func(i, j int) bool {
+		return masteries[i].Points > masteries[j].Points
+	})
+
+	for i, mastery := range masteries {
+		entry, ok := entries[mastery.Champion]
+
+		if !ok && i >= top {
+			continue
+		}
+
+		if !ok {
+			entry = &PoolEntry{Champion: mastery.Champion}
+			entries[mastery.Champion] = entry
+		}
+
+		entry.Mastery = mastery
+	}
+
+	pool := make([]*PoolEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		pool = append(pool, entry)
+	}
+
+	sort.Slice(pool, func(i, j int) bool {
+		if pool[i].points() != pool[j].points() {
+			return pool[i].points() > pool[j].points()
+		}
+
+		if pool[i].games() != pool[j].games() {
+			return pool[i].games() > pool[j].games()
+		}
+
+		return pool[i].Champion < pool[j].Champion
+	})
+
+	return pool
+}
+
+func (p PoolEntry) points() int {
+	if p.Mastery == nil {
+		return 0
+	}
+
+	return p.Mastery.Points
+}
+
+func (p PoolEntry) games() int {
+	if p.Analytics == nil {
+		return 0
+	}
+
+	return p.Analytics.Size
+}
+
 type AnalyticsByPosition map[model.Position]*Analytics
 
 func AnalyzeByPosition(metrics []model.MatchMetrics) AnalyticsByPosition {
@@ -297,3 +605,100 @@ func byPosition(metrics []model.MatchMetrics) positionMetrics {
 
 	return positionMetrics
 }
+
+type AnalyticsByPatch map[string]*Analytics
+
+func AnalyzeByPatch(metrics []model.MatchMetrics) AnalyticsByPatch {
+	metricsByPatch := byPatch(metrics)
+
+	analyticsByPatch := make(AnalyticsByPatch)
+
+	for patch, metrics := range metricsByPatch {
+		result := Analyze(metrics)
+
+		// Sample size too small; reject
+		if result.Size < 2 {
+			continue
+		}
+
+		analyticsByPatch[patch] = result
+	}
+
+	return analyticsByPatch
+}
+
+type patchMetrics map[string][]model.MatchMetrics
+
+// byPatch groups metrics by patch, skipping metrics saved before the patch
+// was stored.
+func byPatch(metrics []model.MatchMetrics) patchMetrics {
+	patchMetrics := make(patchMetrics)
+
+	for _, metric := range metrics {
+		if metric.Patch == "" {
+			continue
+		}
+
+		patchMetrics[metric.Patch] = append(patchMetrics[metric.Patch], metric)
+	}
+
+	return patchMetrics
+}
+
+// Choice is a build, rune page or pair of summoner spells, and how the games
+// it was chosen in went.
+type Choice struct {
+	Name  string
+	Games int
+	Wins  int
+}
+
+func (c Choice) WinRate() float64 {
+	return float64(c.Wins) / float64(c.Games)
+}
+
+// CommonChoices groups metrics by the name of a choice, skipping metrics
+// without one, and keeps the top most chosen.
+func CommonChoices(metrics []model.MatchMetrics, name func(model.MatchMetrics) string, top int) []*Choice {
+	byName := make(map[string]*Choice)
+
+	for _, metric := range metrics {
+		n := name(metric)
+
+		if n == "" {
+			continue
+		}
+
+		choice, ok := byName[n]
+		if !ok {
+			choice = &Choice{Name: n}
+			byName[n] = choice
+		}
+
+		choice.Games++
+
+		if metric.Win {
+			choice.Wins++
+		}
+	}
+
+	choices := make([]*Choice, 0, len(byName))
+
+	for _, choice := range byName {
+		choices = append(choices, choice)
+	}
+
+	sort.Slice(choices, func(i, j int) bool {
+		if choices[i].Games != choices[j].Games {
+			return choices[i].Games > choices[j].Games
+		}
+
+		return choices[i].Name < choices[j].Name
+	})
+
+	if len(choices) > top {
+		choices = choices[:top]
+	}
+
+	return choices
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/haydenheroux/lolscout/pkg/model"
+)
+
+func TestAnalyzeUnversioned(t *testing.T) {
+	metrics := []model.MatchMetrics{
+		{Kills: 2, DamageDealtPerMinute: 900, Win: true},
+		{Kills: 4, DamageDealtPerMinute: 1100},
+	}
+
+	a := Analyze(metrics)
+
+	if !a.DamageDealtPerMinute.Empty() || !a.DamageTakenShare.Empty() {
+		t.Errorf("damage of unversioned metrics = %s, %s, want empty", a.DamageDealtPerMinute, a.DamageTakenShare)
+	}
+
+	if a.Kills.Empty() || a.Kills.Mean != 3 {
+		t.Errorf("kills = %s, want mean 3", a.Kills)
+	}
+
+	mean := a.Mean()
+
+	if !math.IsNaN(mean.DamageDealtPerMinute) {
+		t.Errorf("mean damage dealt per minute = %f, want NaN", mean.DamageDealtPerMinute)
+	}
+
+	if mean.Kills != 3 {
+		t.Errorf("mean kills = %f, want 3", mean.Kills)
+	}
+
+	if got := a.DamageDealtPerMinute.String(); got != "-" {
+		t.Errorf("empty norm shown as %q, want \"-\"", got)
+	}
+}
+
+func TestAnalyzeVersioned(t *testing.T) {
+	metrics := []model.MatchMetrics{
+		{Version: 1, DamageDealtPerMinute: 900},
+		{Version: 1, DamageDealtPerMinute: 1100},
+		{DamageDealtPerMinute: 5000},
+	}
+
+	a := Analyze(metrics)
+
+	if a.DamageDealtPerMinute.Empty() || a.DamageDealtPerMinute.Mean != 1000 {
+		t.Errorf("damage dealt per minute = %s, want mean 1000", a.DamageDealtPerMinute)
+	}
+}
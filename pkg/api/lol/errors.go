@@ -0,0 +1,23 @@
+package api
+
+import "fmt"
+
+// MatchError reports why a scan stopped early. It is returned alongside the
+// matches that were fetched before the failure.
+type MatchError struct {
+	// ID of the match that could not be fetched; empty if listing match IDs failed.
+	MatchID string
+	Err     error
+}
+
+func (e *MatchError) Error() string {
+	if len(e.MatchID) == 0 {
+		return fmt.Sprintf("could not list matches: %v", e.Err)
+	}
+
+	return fmt.Sprintf("could not get match %s: %v", e.MatchID, e.Err)
+}
+
+func (e *MatchError) Unwrap() error {
+	return e.Err
+}
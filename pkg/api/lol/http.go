@@ -0,0 +1,42 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/KnutZuidema/golio/api"
+)
+
+func (c client) performRequest(host, endpoint string) ([]byte, error) {
+	req, err := http.NewRequest("GET", c.transport.RiotURL(host, endpoint), nil)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	req.Header.Set("X-Riot-Token", c.APIKey)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.doer.Do(req)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if err, ok := api.StatusToError[res.StatusCode]; ok {
+			return []byte{}, err
+		}
+
+		return []byte{}, api.Error{
+			Message:    "unknown error reason",
+			StatusCode: res.StatusCode,
+		}
+	}
+
+	return body, nil
+}
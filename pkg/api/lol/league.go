@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	RankedSolo = "RANKED_SOLO_5x5"
+	RankedFlex = "RANKED_FLEX_SR"
+)
+
+// RankedQueues are the queues whose standings are kept.
+var RankedQueues = []string{RankedSolo, RankedFlex}
+
+type LeagueEntry struct {
+	QueueType    string `json:"queueType"`
+	Tier         string `json:"tier"`
+	Rank         string `json:"rank"`
+	LeaguePoints int    `json:"leaguePoints"`
+	Wins         int    `json:"wins"`
+	Losses       int    `json:"losses"`
+}
+
+// LeagueEntries lists the ranked standings of a player, one per queue they
+// are placed in.
+func (c client) LeagueEntries(puuid string) ([]*LeagueEntry, error) {
+	payload, err := c.performRequest(c.Region.Platform(), fmt.Sprintf("/lol/league/v4/entries/by-puuid/%s", puuid))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*LeagueEntry
+
+	if err := json.Unmarshal(payload, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -1,25 +1,49 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/KnutZuidema/golio"
 	"github.com/KnutZuidema/golio/api"
 	"github.com/KnutZuidema/golio/riot/lol"
+	riot "github.com/haydenheroux/lolscout/pkg/api/riot"
+	"github.com/haydenheroux/lolscout/pkg/api/transport"
 	log "github.com/sirupsen/logrus"
 )
 
+const defaultWorkers = 8
+
 type client struct {
-	APIKey string
-	Client *golio.Client
+	APIKey    string
+	Client    *golio.Client
+	Region    riot.Region
+	Workers   int
+	transport transport.Transport
+	doer      *http.Client
 }
 
-func CreateClient(apiKey string) client {
+func CreateClient(apiKey string, region riot.Region, t transport.Transport) client {
+	doer := t.RiotClient()
+
 	return client{
 		APIKey: apiKey,
 		Client: golio.NewClient(apiKey,
-			golio.WithRegion(api.RegionNorthAmerica),
+			golio.WithRegion(api.Region(region.Platform())),
+			golio.WithClient(doer),
 			golio.WithLogger(log.New())),
+		Region:    region,
+		Workers:   defaultWorkers,
+		transport: t,
+		doer:      doer,
 	}
 }
 
@@ -46,18 +70,154 @@ func (c client) Get(summoner *lol.Summoner, queues []QueueType) getter {
 	}
 }
 
-func (g getter) options() []*lol.MatchListOptions {
-	var options []*lol.MatchListOptions
+const matchIdsPageSize = 100
 
-	for _, queue := range g.queues {
-		q := int(queue)
+// matchIds lists one page of the summoner's match IDs in a queue, newest
+// first. Zero times leave the window unbounded.
+func (g getter) matchIds(queue QueueType, startTime, endTime time.Time, start, count int) ([]string, error) {
+	query := url.Values{}
+
+	query.Set("queue", fmt.Sprint(int(queue)))
+	query.Set("start", fmt.Sprint(start))
+	query.Set("count", fmt.Sprint(count))
+
+	if !startTime.IsZero() {
+		query.Set("startTime", fmt.Sprint(startTime.Unix()))
+	}
+
+	if !endTime.IsZero() {
+		query.Set("endTime", fmt.Sprint(endTime.Unix()))
+	}
+
+	endpoint := fmt.Sprintf("/lol/match/v5/matches/by-puuid/%s/ids?%s", g.summoner.PUUID, query.Encode())
+
+	payload, err := g.client.performRequest(g.client.Region.Route(), endpoint)
+	if err != nil {
+		return []string{}, err
+	}
+
+	var matchIds []string
+
+	if err := json.Unmarshal(payload, &matchIds); err != nil {
+		return []string{}, err
+	}
+
+	return matchIds, nil
+}
+
+// MatchPayload is a match and its timeline along with the JSON they were
+// decoded from. The timeline is nil if it is not available.
+type MatchPayload struct {
+	Match           *lol.Match
+	Payload         []byte
+	Timeline        *Timeline
+	TimelinePayload []byte
+}
+
+// Match downloads a single match, keeping the original JSON so that fields
+// not modelled by lol.Match are not lost.
+func (c client) Match(matchId string) (*MatchPayload, error) {
+	payload, err := c.performRequest(c.Region.Route(), fmt.Sprintf("/lol/match/v5/matches/%s", matchId))
+	if err != nil {
+		return nil, err
+	}
+
+	var match lol.Match
 
-		options = append(options, &lol.MatchListOptions{
-			Queue: &q,
-		})
+	if err := json.Unmarshal(payload, &match); err != nil {
+		return nil, err
 	}
 
-	return options
+	return &MatchPayload{
+		Match:   &match,
+		Payload: payload,
+	}, nil
+}
+
+type fetchResult struct {
+	match *MatchPayload
+	err   error
+}
+
+// fetch downloads the matches with the given IDs using a pool of workers.
+// Results are returned in the same order as the IDs.
+func (c client) fetch(matchIds []string) []fetchResult {
+	results := make([]fetchResult, len(matchIds))
+
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < max(c.Workers, 1); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				match, err := c.Match(matchIds[i])
+
+				if err == nil {
+					match.Timeline, match.TimelinePayload, err = c.Timeline(matchIds[i])
+					// Older matches have no timeline; any other failure leaves
+					// the match to be fetched again
+					if errors.Is(err, api.ErrNotFound) {
+						log.Debugf("no timeline for %s: %v", matchIds[i], err)
+						err = nil
+					}
+				}
+
+				results[i] = fetchResult{match, err}
+			}
+		}()
+	}
+
+	for i := range matchIds {
+		indices <- i
+	}
+
+	close(indices)
+
+	wg.Wait()
+
+	return results
+}
+
+// fetchAll downloads the matches with the given IDs. If any match could not
+// be downloaded, the others are returned along with the first failure.
+func (c client) fetchAll(matchIds []string) ([]*MatchPayload, error) {
+	var matches []*MatchPayload
+	var err error
+
+	for i, result := range c.fetch(matchIds) {
+		if result.err != nil {
+			if err == nil {
+				err = &MatchError{MatchID: matchIds[i], Err: result.err}
+			}
+
+			continue
+		}
+
+		matches = append(matches, result.match)
+	}
+
+	return matches, err
+}
+
+// fetchUntilError downloads the matches with the given IDs, stopping at the
+// first match that could not be downloaded.
+func (c client) fetchUntilError(matchIds []string) ([]*MatchPayload, error) {
+	var matches []*MatchPayload
+
+	for i, result := range c.fetch(matchIds) {
+		if result.err != nil {
+			return matches, &MatchError{MatchID: matchIds[i], Err: result.err}
+		}
+
+		matches = append(matches, result.match)
+	}
+
+	return matches, nil
 }
 
 func (g getter) Recent(name string) ([]*lol.Match, error) {
@@ -68,13 +228,12 @@ func (g getter) Recent(name string) ([]*lol.Match, error) {
 
 	var matches []*lol.Match
 
-	for _, matchId := range matchIds {
-		match, err := g.client.Client.Riot.LoL.Match.Get(matchId)
-		if err != nil {
-			return []*lol.Match{}, err
+	for _, result := range g.client.fetch(matchIds) {
+		if result.err != nil {
+			return []*lol.Match{}, result.err
 		}
 
-		matches = append(matches, match)
+		matches = append(matches, result.match.Match)
 	}
 
 	return matches, nil
@@ -83,52 +242,153 @@ func (g getter) Recent(name string) ([]*lol.Match, error) {
 func (g getter) Until(summoner *lol.Summoner, predicate func(*lol.Match) bool) ([]*lol.Match, error) {
 	var matches []*lol.Match
 
-	for _, options := range g.options() {
-		for result := range g.client.Client.Riot.LoL.Match.ListStream(summoner.PUUID, options) {
-			if result.Error != nil {
-				break
-			}
+	for _, queue := range g.queues {
+		queueMatches, err := g.until(queue, predicate)
 
-			match, err := g.client.Client.Riot.LoL.Match.Get(result.MatchID)
-			if err != nil {
-				break
+		matches = append(matches, queueMatches...)
+
+		if err != nil {
+			return matches, err
+		}
+	}
+
+	return matches, nil
+}
+
+func (g getter) until(queue QueueType, predicate func(*lol.Match) bool) ([]*lol.Match, error) {
+	var matches []*lol.Match
+
+	batchSize := max(g.client.Workers, 1)
+
+	for start := 0; ; start += matchIdsPageSize {
+		matchIds, err := g.matchIds(queue, time.Time{}, time.Time{}, start, matchIdsPageSize)
+		if err != nil {
+			return matches, &MatchError{Err: err}
+		}
+
+		for i := 0; i < len(matchIds); i += batchSize {
+			batch := matchIds[i:min(i+batchSize, len(matchIds))]
+
+			fetched, err := g.client.fetchUntilError(batch)
+
+			for _, match := range fetched {
+				if !predicate(match.Match) {
+					return matches, nil
+				}
+
+				matches = append(matches, match.Match)
 			}
 
-			if !predicate(match) {
-				break
+			if err != nil {
+				return matches, err
 			}
+		}
 
-			matches = append(matches, match)
+		if len(matchIds) < matchIdsPageSize {
+			return matches, nil
 		}
 	}
-
-	return matches, nil
 }
 
 func (g getter) Since(startTime time.Time) ([]*lol.Match, error) {
 	return g.Between(startTime, time.Now())
 }
 
+// Between returns the matches played in the window. If the scan stopped
+// early, the matches fetched so far are returned with a *MatchError.
 func (g getter) Between(startTime time.Time, endTime time.Time) ([]*lol.Match, error) {
-	var matches []*lol.Match
+	matchIds, listErr := g.MatchIDsBetween(startTime, endTime)
+
+	matches, fetchErr := g.client.Matches(matchIds)
+
+	return matches, errors.Join(listErr, fetchErr)
+}
+
+func (g getter) MatchIDsSince(startTime time.Time) ([]string, error) {
+	return g.MatchIDsBetween(startTime, time.Now())
+}
 
-	for _, options := range g.options() {
-		options.StartTime = startTime
-		options.EndTime = endTime
+// MatchIDsBetween lists the IDs of the matches played in the window. If
+// listing fails, the IDs listed so far are returned with a *MatchError.
+func (g getter) MatchIDsBetween(startTime time.Time, endTime time.Time) ([]string, error) {
+	var matchIds []string
 
-		for result := range g.client.Client.Riot.LoL.Match.ListStream(g.summoner.PUUID, options) {
-			if result.Error != nil {
+	for _, queue := range g.queues {
+		for start := 0; ; start += matchIdsPageSize {
+			page, err := g.matchIds(queue, startTime, endTime, start, matchIdsPageSize)
+			if err != nil {
+				return matchIds, &MatchError{Err: err}
+			}
+
+			matchIds = append(matchIds, page...)
+
+			if len(page) < matchIdsPageSize {
 				break
 			}
+		}
+	}
+
+	return matchIds, nil
+}
 
-			match, err := g.client.Client.Riot.LoL.Match.Get(result.MatchID)
+// LastMatchIDs lists the IDs of the summoner's n most recent matches across
+// all of the getter's queues, or of those before a time if it is not zero.
+// If listing fails, the IDs listed so far are returned with a *MatchError.
+func (g getter) LastMatchIDs(n int, before time.Time) ([]string, error) {
+	var matchIds []string
+
+	for _, queue := range g.queues {
+		for start := 0; start < n; start += matchIdsPageSize {
+			count := min(n-start, matchIdsPageSize)
+
+			page, err := g.matchIds(queue, time.Time{}, before, start, count)
 			if err != nil {
-				break
+				return newest(matchIds, n), &MatchError{Err: err}
 			}
 
-			matches = append(matches, match)
+			matchIds = append(matchIds, page...)
+
+			if len(page) < count {
+				break
+			}
 		}
 	}
 
-	return matches, nil
+	return newest(matchIds, n), nil
+}
+
+// newest returns the n most recent of the match IDs. Match IDs are of the
+// form "NA1_4912345678", where the game ID increases over time.
+func newest(matchIds []string, n int) []string {
+	gameId := func(matchId string) int64 {
+		_, id, _ := strings.Cut(matchId, "_")
+
+		gameId, _ := strconv.ParseInt(id, 10, 64)
+
+		return gameId
+	}
+
+	sort.SliceStable(matchIds, func(i, j int) bool {
+		return gameId(matchIds[i]) > gameId(matchIds[j])
+	})
+
+	return matchIds[:min(n, len(matchIds))]
+}
+
+func (c client) Matches(matchIds []string) ([]*lol.Match, error) {
+	payloads, err := c.MatchPayloads(matchIds)
+
+	matches := make([]*lol.Match, len(payloads))
+
+	for i, payload := range payloads {
+		matches[i] = payload.Match
+	}
+
+	return matches, err
+}
+
+// MatchPayloads downloads the matches with the given IDs. If any match could
+// not be downloaded, the others are returned with a *MatchError.
+func (c client) MatchPayloads(matchIds []string) ([]*MatchPayload, error) {
+	return c.fetchAll(matchIds)
 }
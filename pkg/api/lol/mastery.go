@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type ChampionMastery struct {
+	ChampionID     int `json:"championId"`
+	ChampionLevel  int `json:"championLevel"`
+	ChampionPoints int `json:"championPoints"`
+	// Milliseconds since the epoch
+	LastPlayTime int64 `json:"lastPlayTime"`
+}
+
+func (c client) ChampionMasteries(puuid string) ([]*ChampionMastery, error) {
+	payload, err := c.performRequest(c.Region.Platform(), fmt.Sprintf("/lol/champion-mastery/v4/champion-masteries/by-puuid/%s", puuid))
+	if err != nil {
+		return nil, err
+	}
+
+	var masteries []*ChampionMastery
+
+	if err := json.Unmarshal(payload, &masteries); err != nil {
+		return nil, err
+	}
+
+	return masteries, nil
+}
+
+// ChampionNames maps champion IDs to the names used by match-v5, such as
+// "MonkeyKing" for Wukong.
+func (c client) ChampionNames() (map[int]string, error) {
+	champions, err := c.Client.DataDragon.GetChampions()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string)
+
+	for _, champion := range champions {
+		id, err := strconv.Atoi(champion.Key)
+		if err != nil {
+			continue
+		}
+
+		names[id] = champion.ID
+	}
+
+	return names, nil
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+
+	riot "github.com/haydenheroux/lolscout/pkg/api/riot"
+)
+
+// RiotIDs maps the PUUIDs of a match's participants to the Riot IDs they
+// played the match with, which lol.Match does not model.
+func RiotIDs(payload []byte) (map[string]riot.RiotID, error) {
+	var match struct {
+		Info struct {
+			Participants []struct {
+				PUUID          string `json:"puuid"`
+				RiotIDGameName string `json:"riotIdGameName"`
+				RiotIDName     string `json:"riotIdName"`
+				RiotIDTagline  string `json:"riotIdTagline"`
+			} `json:"participants"`
+		} `json:"info"`
+	}
+
+	if err := json.Unmarshal(payload, &match); err != nil {
+		return nil, err
+	}
+
+	riotIds := make(map[string]riot.RiotID)
+
+	for _, participant := range match.Info.Participants {
+		gameName := participant.RiotIDGameName
+
+		// Matches from before Riot IDs replaced summoner names
+		if gameName == "" {
+			gameName = participant.RiotIDName
+		}
+
+		riotIds[participant.PUUID] = riot.RiotID{GameName: gameName, TagLine: participant.RiotIDTagline}
+	}
+
+	return riotIds, nil
+}
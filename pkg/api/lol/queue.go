@@ -1,31 +1,165 @@
 package api
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type QueueType int
 
 const (
-	normal QueueType = 400
-	ranked QueueType = 420
-	clash  QueueType = 700
+	custom            QueueType = 0
+	normal            QueueType = 400
+	ranked            QueueType = 420
+	blind             QueueType = 430
+	flex              QueueType = 440
+	aram              QueueType = 450
+	swiftplay         QueueType = 480
+	quickplay         QueueType = 490
+	clash             QueueType = 700
+	aramClash         QueueType = 720
+	coopIntro         QueueType = 870
+	coopBeginner      QueueType = 880
+	coopIntermediate  QueueType = 890
+	urf               QueueType = 900
+	oneForAll         QueueType = 1020
+	nexusBlitz        QueueType = 1300
+	ultimateSpellbook QueueType = 1400
+	arena             QueueType = 1700
+	arenaDuo          QueueType = 1710
+	pickURF           QueueType = 1900
 )
 
-func (q QueueType) String() string {
-	s, _ := map[QueueType]string{
-		normal: "Normal",
-		ranked: "Ranked",
-		clash:  "Clash",
-	}[q]
+// MapType is the map a queue is played on, using the map IDs of match-v5.
+type MapType int
+
+const (
+	MapUnknown       MapType = 0
+	MapSummonersRift MapType = 11
+	MapHowlingAbyss  MapType = 12
+	MapNexusBlitz    MapType = 21
+	MapArena         MapType = 30
+)
+
+func (m MapType) String() string {
+	s, _ := map[MapType]string{
+		MapUnknown:       "Unknown",
+		MapSummonersRift: "Summoner's Rift",
+		MapHowlingAbyss:  "Howling Abyss",
+		MapNexusBlitz:    "Nexus Blitz",
+		MapArena:         "Rings of Wrath",
+	}[m]
 
 	return s
 }
 
+type queueInfo struct {
+	slug    string
+	name    string
+	mapType MapType
+}
+
+var catalogue = map[QueueType]queueInfo{
+	custom:            {"custom", "Custom", MapUnknown},
+	normal:            {"normal", "Normal", MapSummonersRift},
+	ranked:            {"ranked", "Ranked", MapSummonersRift},
+	blind:             {"blind", "Blind", MapSummonersRift},
+	flex:              {"flex", "Flex", MapSummonersRift},
+	aram:              {"aram", "ARAM", MapHowlingAbyss},
+	swiftplay:         {"swiftplay", "Swiftplay", MapSummonersRift},
+	quickplay:         {"quickplay", "Quickplay", MapSummonersRift},
+	clash:             {"clash", "Clash", MapSummonersRift},
+	aramClash:         {"aram-clash", "ARAM Clash", MapHowlingAbyss},
+	coopIntro:         {"coop-intro", "Co-op vs. AI Intro", MapSummonersRift},
+	coopBeginner:      {"coop-beginner", "Co-op vs. AI Beginner", MapSummonersRift},
+	coopIntermediate:  {"coop-intermediate", "Co-op vs. AI Intermediate", MapSummonersRift},
+	urf:               {"urf", "ARURF", MapSummonersRift},
+	oneForAll:         {"one-for-all", "One for All", MapSummonersRift},
+	nexusBlitz:        {"nexus-blitz", "Nexus Blitz", MapNexusBlitz},
+	ultimateSpellbook: {"ultimate-spellbook", "Ultimate Spellbook", MapSummonersRift},
+	arena:             {"arena", "Arena", MapArena},
+	arenaDuo:          {"arena-duo", "Arena", MapArena},
+	pickURF:           {"pick-urf", "Pick URF", MapSummonersRift},
+}
+
+func (q QueueType) String() string {
+	return catalogue[q].name
+}
+
+// Slug is the name used to select the queue on the command line.
+func (q QueueType) Slug() string {
+	return catalogue[q].slug
+}
+
+func (q QueueType) Map() MapType {
+	return catalogue[q].mapType
+}
+
+// ParseQueue accepts either a queue's slug or its numeric ID.
+func ParseQueue(s string) (QueueType, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if id, err := strconv.Atoi(s); err == nil {
+		if _, ok := catalogue[QueueType(id)]; ok {
+			return QueueType(id), nil
+		}
+	}
+
+	for queue, info := range catalogue {
+		if info.slug == s {
+			return queue, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown queue %s", s)
+}
+
 type queues struct {
-	Normal QueueType
-	Ranked QueueType
-	Clash  QueueType
+	Custom            QueueType
+	Normal            QueueType
+	Ranked            QueueType
+	Blind             QueueType
+	Flex              QueueType
+	ARAM              QueueType
+	Swiftplay         QueueType
+	Quickplay         QueueType
+	Clash             QueueType
+	ARAMClash         QueueType
+	CoopIntro         QueueType
+	CoopBeginner      QueueType
+	CoopIntermediate  QueueType
+	URF               QueueType
+	OneForAll         QueueType
+	NexusBlitz        QueueType
+	UltimateSpellbook QueueType
+	Arena             QueueType
+	ArenaDuo          QueueType
+	PickURF           QueueType
 }
 
 var Queue = queues{
-	Normal: normal,
-	Ranked: ranked,
-	Clash:  clash,
+	Custom:            custom,
+	Normal:            normal,
+	Ranked:            ranked,
+	Blind:             blind,
+	Flex:              flex,
+	ARAM:              aram,
+	Swiftplay:         swiftplay,
+	Quickplay:         quickplay,
+	Clash:             clash,
+	ARAMClash:         aramClash,
+	CoopIntro:         coopIntro,
+	CoopBeginner:      coopBeginner,
+	CoopIntermediate:  coopIntermediate,
+	URF:               urf,
+	OneForAll:         oneForAll,
+	NexusBlitz:        nexusBlitz,
+	UltimateSpellbook: ultimateSpellbook,
+	Arena:             arena,
+	ArenaDuo:          arenaDuo,
+	PickURF:           pickURF,
 }
+
+// DefaultQueues are the queues scanned when none are given.
+var DefaultQueues = []QueueType{Queue.Normal, Queue.Ranked, Queue.Clash}
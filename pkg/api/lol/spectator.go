@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/KnutZuidema/golio/api"
+)
+
+var ErrNotInGame = errors.New("player is not in a game")
+
+type CurrentGame struct {
+	GameID            int64                     `json:"gameId"`
+	GameMode          string                    `json:"gameMode"`
+	GameType          string                    `json:"gameType"`
+	GameQueueConfigID int                       `json:"gameQueueConfigId"`
+	MapID             int                       `json:"mapId"`
+	GameLength        int                       `json:"gameLength"`
+	Participants      []*CurrentGameParticipant `json:"participants"`
+}
+
+type CurrentGameParticipant struct {
+	PUUID      string `json:"puuid"`
+	RiotID     string `json:"riotId"`
+	TeamID     int    `json:"teamId"`
+	ChampionID int    `json:"championId"`
+	Bot        bool   `json:"bot"`
+}
+
+// ActiveGame finds the game a player is currently in.
+func (c client) ActiveGame(puuid string) (*CurrentGame, error) {
+	payload, err := c.performRequest(c.Region.Platform(), fmt.Sprintf("/lol/spectator/v5/active-games/by-summoner/%s", puuid))
+	if errors.Is(err, api.ErrNotFound) {
+		return nil, ErrNotInGame
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var game CurrentGame
+
+	if err := json.Unmarshal(payload, &game); err != nil {
+		return nil, err
+	}
+
+	return &game, nil
+}
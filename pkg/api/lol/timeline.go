@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/KnutZuidema/golio/riot/lol"
+)
+
+type Timeline struct {
+	Metadata TimelineMetadata `json:"metadata"`
+	Info     TimelineInfo     `json:"info"`
+}
+
+type TimelineMetadata struct {
+	MatchID      string   `json:"matchId"`
+	Participants []string `json:"participants"`
+}
+
+type TimelineInfo struct {
+	FrameInterval int                    `json:"frameInterval"`
+	Frames        []*lol.MatchFrame      `json:"frames"`
+	Participants  []*TimelineParticipant `json:"participants"`
+}
+
+type TimelineParticipant struct {
+	ParticipantID int    `json:"participantId"`
+	PUUID         string `json:"puuid"`
+}
+
+// Frame returns the participant's frame at the given minute, or nil if the
+// timeline does not extend that far.
+func (t *Timeline) Frame(minute int, participantId int) *lol.ParticipantFrame {
+	index := minute
+
+	if t.Info.FrameInterval > 0 {
+		index = minute * 60000 / t.Info.FrameInterval
+	}
+
+	if index >= len(t.Info.Frames) {
+		return nil
+	}
+
+	frame, ok := t.Info.Frames[index].ParticipantFrames[fmt.Sprint(participantId)]
+	if !ok {
+		return nil
+	}
+
+	return frame
+}
+
+func (c client) Timeline(matchId string) (*Timeline, []byte, error) {
+	payload, err := c.performRequest(c.Region.Route(), fmt.Sprintf("/lol/match/v5/matches/%s/timeline", matchId))
+	if err != nil {
+		return nil, []byte{}, err
+	}
+
+	var timeline Timeline
+
+	if err := json.Unmarshal(payload, &timeline); err != nil {
+		return nil, []byte{}, err
+	}
+
+	return &timeline, payload, nil
+}
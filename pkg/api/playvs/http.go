@@ -7,14 +7,14 @@ import (
 	"net/http"
 )
 
-func performRequest(method, url string, payload map[string]interface{}) ([]byte, error) {
+func (c client) performRequest(method, endpoint string, payload map[string]interface{}) ([]byte, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return []byte{}, err
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, bytes.NewReader(payloadBytes))
+	client := c.transport.Client()
+	req, err := http.NewRequest(method, c.transport.PlayVSURL(endpoint), bytes.NewReader(payloadBytes))
 
 	if err != nil {
 		return []byte{}, err
@@ -3,12 +3,18 @@ package playvs
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/haydenheroux/lolscout/pkg/api/transport"
 )
 
-type client struct{}
+type client struct {
+	transport transport.Transport
+}
 
-func CreateClient() client {
-	return client{}
+func CreateClient(t transport.Transport) client {
+	return client{
+		transport: t,
+	}
 }
 
 // TODO examine other libraries to see if this is how they handle their magic constants
@@ -26,12 +32,14 @@ const (
 )
 
 type getter struct {
+	client     client
 	region     region
 	metaseason metaSeason
 }
 
 func (c client) GetRegion(region region) getter {
 	return getter{
+		client:     c,
 		region:     region,
 		metaseason: MetaSeason,
 	}
@@ -74,7 +82,7 @@ type team struct {
 }
 
 const (
-	playVsEndpoint = "https://api.playvs.com/graphql"
+	playVsEndpoint = "/graphql"
 )
 
 func (g getter) Teams() ([]*team, error) {
@@ -99,7 +107,7 @@ func (g getter) Teams() ([]*team, error) {
 		},
 	}
 
-	result, err := performRequest("POST", playVsEndpoint, payload)
+	result, err := g.client.performRequest("POST", playVsEndpoint, payload)
 	if err != nil {
 		return []*team{}, err
 	}
@@ -385,7 +393,7 @@ func (tg teamGetter) DisplayNames() ([]string, error) {
 		},
 	}
 
-	result, err := performRequest("POST", playVsEndpoint, payload)
+	result, err := tg.getter.client.performRequest("POST", playVsEndpoint, payload)
 	if err != nil {
 		return []string{}, err
 	}
@@ -0,0 +1,300 @@
+package ratelimit
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	appRateLimitHeader         = "X-App-Rate-Limit"
+	appRateLimitCountHeader    = "X-App-Rate-Limit-Count"
+	methodRateLimitHeader      = "X-Method-Rate-Limit"
+	methodRateLimitCountHeader = "X-Method-Rate-Limit-Count"
+)
+
+// Limits of a development API key; used until the first response reports the real limits.
+const defaultAppRateLimit = "20:1,100:120"
+
+type rateLimit struct {
+	count  int
+	window time.Duration
+}
+
+// parseRateLimits parses a rate limit header of the form "20:1,100:120",
+// where each pair is a request count and a window in seconds.
+func parseRateLimits(header string) []rateLimit {
+	var limits []rateLimit
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(pair), ":")
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		count, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		// Limits that allow no requests, or no time to make them, cannot
+		// be waited out; counts of zero change nothing when syncing
+		if count < 1 || seconds < 1 {
+			continue
+		}
+
+		limits = append(limits, rateLimit{
+			count:  count,
+			window: time.Duration(seconds) * time.Second,
+		})
+	}
+
+	return limits
+}
+
+type bucket struct {
+	limits []rateLimit
+	times  []time.Time
+}
+
+func (b *bucket) longestWindow() time.Duration {
+	var longest time.Duration
+
+	for _, limit := range b.limits {
+		longest = max(longest, limit.window)
+	}
+
+	return longest
+}
+
+func (b *bucket) prune(now time.Time) {
+	start := now.Add(-b.longestWindow())
+
+	i := 0
+	for i < len(b.times) && !b.times[i].After(start) {
+		i++
+	}
+
+	b.times = b.times[i:]
+}
+
+func (b *bucket) countSince(start time.Time) int {
+	count := 0
+
+	for _, t := range b.times {
+		if t.After(start) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// wait returns how long to wait before another request fits in every window.
+func (b *bucket) wait(now time.Time) time.Duration {
+	b.prune(now)
+
+	var wait time.Duration
+
+	for _, limit := range b.limits {
+		if b.countSince(now.Add(-limit.window)) < limit.count {
+			continue
+		}
+
+		expiring := b.times[len(b.times)-limit.count]
+		wait = max(wait, expiring.Add(limit.window).Sub(now))
+	}
+
+	return wait
+}
+
+func (b *bucket) add(now time.Time) {
+	b.times = append(b.times, now)
+}
+
+// sync accounts for requests the server has counted but this process has
+// not, such as those made by another process using the same key.
+func (b *bucket) sync(now time.Time, counts []rateLimit) {
+	for _, count := range counts {
+		missing := count.count - b.countSince(now.Add(-count.window))
+
+		for i := 0; i < missing; i++ {
+			b.add(now)
+		}
+	}
+}
+
+func (b *bucket) update(now time.Time, limitsHeader, countsHeader string) {
+	if limits := parseRateLimits(limitsHeader); len(limits) > 0 {
+		b.limits = limits
+	}
+
+	b.sync(now, parseRateLimits(countsHeader))
+}
+
+const (
+	maxRetries  = 5
+	baseBackoff = time.Second
+	maxBackoff  = 32 * time.Second
+)
+
+// Limiter is an HTTP transport that delays requests to stay under the
+// application and method rate limits reported by the Riot API. Requests that
+// are rate limited anyway, or that fail with a server error, are retried.
+type Limiter struct {
+	next    http.RoundTripper
+	mutex   sync.Mutex
+	app     map[string]*bucket
+	methods map[string]*bucket
+}
+
+func New(next http.RoundTripper) *Limiter {
+	return &Limiter{
+		next:    next,
+		app:     make(map[string]*bucket),
+		methods: make(map[string]*bucket),
+	}
+}
+
+// methodKey groups requests by host and API resource, for example
+// "americas.api.riotgames.com/lol/match/v5/matches". Methods that share a
+// resource also share a bucket, which is stricter than necessary but safe.
+func methodKey(req *http.Request) string {
+	segments := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 5)
+
+	if len(segments) > 4 {
+		segments = segments[:4]
+	}
+
+	return req.URL.Host + "/" + strings.Join(segments, "/")
+}
+
+func (l *Limiter) buckets(req *http.Request) (*bucket, *bucket) {
+	host := req.URL.Host
+
+	app, ok := l.app[host]
+	if !ok {
+		app = &bucket{limits: parseRateLimits(defaultAppRateLimit)}
+		l.app[host] = app
+	}
+
+	key := methodKey(req)
+
+	method, ok := l.methods[key]
+	if !ok {
+		method = &bucket{}
+		l.methods[key] = method
+	}
+
+	return app, method
+}
+
+func (l *Limiter) acquire(req *http.Request) {
+	for {
+		l.mutex.Lock()
+
+		now := time.Now()
+		app, method := l.buckets(req)
+		wait := max(app.wait(now), method.wait(now))
+
+		if wait <= 0 {
+			app.add(now)
+			method.add(now)
+			l.mutex.Unlock()
+			return
+		}
+
+		l.mutex.Unlock()
+
+		time.Sleep(wait)
+	}
+}
+
+func (l *Limiter) release(req *http.Request, res *http.Response) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	app, method := l.buckets(req)
+
+	app.update(now, res.Header.Get(appRateLimitHeader), res.Header.Get(appRateLimitCountHeader))
+	method.update(now, res.Header.Get(methodRateLimitHeader), res.Header.Get(methodRateLimitCountHeader))
+}
+
+// backoff is exponential in the attempt, with half of it randomized so that
+// concurrent requests do not retry in lockstep.
+func backoff(attempt int) time.Duration {
+	backoff := min(baseBackoff<<attempt, maxBackoff)
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
+
+// retryAfter is how long a rate limited response asks to wait, if it says.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+func (l *Limiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("cannot retry request with body")
+			}
+
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req.Body = body
+		}
+
+		l.acquire(req)
+
+		res, err := l.next.RoundTrip(req)
+		if err != nil {
+			return res, err
+		}
+
+		l.release(req, res)
+
+		retryable := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+
+		if !retryable || attempt >= maxRetries {
+			return res, nil
+		}
+
+		wait := backoff(attempt)
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			if seconds, ok := retryAfter(res); ok {
+				wait = seconds
+			}
+		}
+
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		log.Infof("%s from %s, retrying in %s", res.Status, req.URL.Host, wait.Round(time.Millisecond))
+
+		time.Sleep(wait)
+	}
+}
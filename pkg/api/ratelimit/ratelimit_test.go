@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(offsets ...time.Duration) []time.Time {
+	times := make([]time.Time, len(offsets))
+
+	for i, offset := range offsets {
+		times[i] = t0.Add(offset)
+	}
+
+	return times
+}
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []rateLimit
+	}{
+		{"", nil},
+		{"20:1", []rateLimit{{20, time.Second}}},
+		{"20:1,100:120", []rateLimit{{20, time.Second}, {100, 120 * time.Second}}},
+		{" 20:1 , 100:120 ", []rateLimit{{20, time.Second}, {100, 120 * time.Second}}},
+		{"20,100:120", []rateLimit{{100, 120 * time.Second}}},
+		{"x:1,20:y,1:2:3", nil},
+		{"0:1,-1:1,20:0,20:-1", nil},
+		{"0:1,20:1", []rateLimit{{20, time.Second}}},
+	}
+
+	for _, test := range tests {
+		if got := parseRateLimits(test.header); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseRateLimits(%q) = %v, want %v", test.header, got, test.want)
+		}
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits string
+		times  []time.Time
+		now    time.Time
+		want   time.Duration
+	}{
+		{"empty", "2:1", nil, t0, 0},
+		{"no limits", "", at(0, 0, 0), t0, 0},
+		{"under limit", "2:1", at(0), t0.Add(100 * time.Millisecond), 0},
+		{"at limit", "2:1", at(0, 100*time.Millisecond), t0.Add(200 * time.Millisecond), 800 * time.Millisecond},
+		{"window passed", "2:1", at(0, 100*time.Millisecond), t0.Add(time.Second), 0},
+		{"longer window", "2:1,3:10", at(0, 2*time.Second, 3*time.Second), t0.Add(3500 * time.Millisecond), 6500 * time.Millisecond},
+		{"both windows", "2:1,3:10", at(0, 3*time.Second, 3*time.Second), t0.Add(3500 * time.Millisecond), 6500 * time.Millisecond},
+		{"pruned", "2:1,3:10", at(0, 11*time.Second, 12*time.Second), t0.Add(12500 * time.Millisecond), 0},
+		{"zero count", "0:1", at(0), t0.Add(100 * time.Millisecond), 0},
+		{"negative count", "-1:1,2:1", at(0, 100*time.Millisecond), t0.Add(200 * time.Millisecond), 800 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		b := &bucket{limits: parseRateLimits(test.limits), times: test.times}
+
+		if got := b.wait(test.now); got != test.want {
+			t.Errorf("%s: wait = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBucketSync(t *testing.T) {
+	tests := []struct {
+		name   string
+		times  []time.Time
+		counts string
+		want   int
+	}{
+		{"no counts", at(0), "", 1},
+		{"in sync", at(0, 0), "2:1,2:120", 2},
+		{"missing in short window", nil, "5:1,5:120", 5},
+		{"missing in long window", nil, "5:1,7:120", 7},
+		{"counted elsewhere earlier", at(-30 * time.Second), "1:1,4:120", 4},
+		{"more than server", at(0, 0, 0), "1:1,1:120", 3},
+		{"none counted", at(0), "0:1,0:120", 1},
+	}
+
+	for _, test := range tests {
+		b := &bucket{times: test.times}
+
+		b.sync(t0, parseRateLimits(test.counts))
+
+		if got := len(b.times); got != test.want {
+			t.Errorf("%s: %d requests after sync, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRoundTripRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       int
+	}{
+		{"ok", http.StatusOK, "", 1},
+		{"not found", http.StatusNotFound, "", 1},
+		{"rate limited with retry after", http.StatusTooManyRequests, "0", maxRetries + 1},
+	}
+
+	for _, test := range tests {
+		attempts := 0
+
+		limiter := New(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+
+			header := make(http.Header)
+
+			if test.retryAfter != "" {
+				header.Set("Retry-After", test.retryAfter)
+			}
+
+			return &http.Response{
+				StatusCode: test.status,
+				Status:     http.StatusText(test.status),
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		}))
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.com/lol/match/v5/matches/NA1_1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := limiter.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if res.StatusCode != test.status {
+			t.Errorf("%s: status %d, want %d", test.name, res.StatusCode, test.status)
+		}
+
+		if attempts != test.want {
+			t.Errorf("%s: %d attempts, want %d", test.name, attempts, test.want)
+		}
+	}
+}
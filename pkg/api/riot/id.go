@@ -3,22 +3,86 @@ package riot
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-func Split(id string) (string, string, error) {
-	fields := strings.Split(id, "#")
+// RiotID is a player's game name and tag line, such as "Faker#KR1". Like
+// Riot, comparisons of Riot IDs ignore case.
+type RiotID struct {
+	GameName string
+	TagLine  string
+}
+
+// ParseRiotID parses and validates a Riot ID written as "name#tag". Spaces
+// around the name and tag are ignored.
+func ParseRiotID(s string) (RiotID, error) {
+	i := strings.LastIndex(s, "#")
+	if i < 0 {
+		return RiotID{}, fmt.Errorf("bad riot id %q: missing #tag", s)
+	}
+
+	id := RiotID{
+		GameName: strings.TrimSpace(s[:i]),
+		TagLine:  strings.TrimSpace(s[i+1:]),
+	}
+
+	if err := id.Validate(); err != nil {
+		return RiotID{}, fmt.Errorf("bad riot id %q: %w", s, err)
+	}
+
+	return id, nil
+}
+
+// Validate checks the Riot ID against Riot's rules: a game name of 3 to 16
+// characters without a #, and a tag line of 3 to 5 letters or digits.
+func (id RiotID) Validate() error {
+	if n := utf8.RuneCountInString(id.GameName); n < 3 || n > 16 {
+		return errors.New("game name must be 3 to 16 characters")
+	}
 
-	if len(fields) != 2 {
-		return "", "", errors.New("bad riot id")
+	if strings.Contains(id.GameName, "#") {
+		return errors.New("game name cannot contain #")
 	}
 
-	gameName := fields[0]
-	tagLine := fields[1]
+	if n := utf8.RuneCountInString(id.TagLine); n < 3 || n > 5 {
+		return errors.New("tag line must be 3 to 5 characters")
+	}
+
+	for _, r := range id.TagLine {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return errors.New("tag line must be letters or digits")
+		}
+	}
+
+	return nil
+}
+
+func (id RiotID) String() string {
+	return fmt.Sprintf("%s#%s", id.GameName, id.TagLine)
+}
+
+// Canonical is the Riot ID case-folded, for comparing Riot IDs.
+func (id RiotID) Canonical() string {
+	return fold(id.GameName) + "#" + fold(id.TagLine)
+}
+
+// Equal reports whether two Riot IDs are the same, ignoring case.
+func (id RiotID) Equal(other RiotID) bool {
+	return id.Canonical() == other.Canonical()
+}
 
-	return gameName, tagLine, nil
+// EscapedPath is the Riot ID as the two path segments "name/tag", escaped
+// for use in a URL.
+func (id RiotID) EscapedPath() string {
+	return url.PathEscape(id.GameName) + "/" + url.PathEscape(id.TagLine)
 }
 
-func Join(gameName, tagLine string) string {
-	return fmt.Sprintf("%s#%s", gameName, tagLine)
+// fold approximates full case folding, which the standard library lacks;
+// upper-casing first folds letters such as final sigma that lower-casing
+// alone does not.
+func fold(s string) string {
+	return strings.ToLower(strings.ToUpper(strings.TrimSpace(s)))
 }
@@ -0,0 +1,110 @@
+package riot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region is a Riot platform, such as "na1" or "euw1".
+type Region string
+
+const (
+	RegionBrazil            Region = "br1"
+	RegionEuropeNordicEast  Region = "eun1"
+	RegionEuropeWest        Region = "euw1"
+	RegionJapan             Region = "jp1"
+	RegionKorea             Region = "kr"
+	RegionLatinAmericaNorth Region = "la1"
+	RegionLatinAmericaSouth Region = "la2"
+	RegionMiddleEast        Region = "me1"
+	RegionNorthAmerica      Region = "na1"
+	RegionOceania           Region = "oc1"
+	RegionPhilippines       Region = "ph2"
+	RegionRussia            Region = "ru"
+	RegionSingapore         Region = "sg2"
+	RegionThailand          Region = "th2"
+	RegionTurkey            Region = "tr1"
+	RegionTaiwan            Region = "tw2"
+	RegionVietnam           Region = "vn2"
+)
+
+const DefaultRegion = RegionNorthAmerica
+
+var regionRoutes = map[Region]string{
+	RegionBrazil:            "americas",
+	RegionEuropeNordicEast:  "europe",
+	RegionEuropeWest:        "europe",
+	RegionJapan:             "asia",
+	RegionKorea:             "asia",
+	RegionLatinAmericaNorth: "americas",
+	RegionLatinAmericaSouth: "americas",
+	RegionMiddleEast:        "europe",
+	RegionNorthAmerica:      "americas",
+	RegionOceania:           "sea",
+	RegionPhilippines:       "sea",
+	RegionRussia:            "europe",
+	RegionSingapore:         "sea",
+	RegionThailand:          "sea",
+	RegionTurkey:            "europe",
+	RegionTaiwan:            "sea",
+	RegionVietnam:           "sea",
+}
+
+var regionAliases = map[string]Region{
+	"br":   RegionBrazil,
+	"eune": RegionEuropeNordicEast,
+	"euw":  RegionEuropeWest,
+	"jp":   RegionJapan,
+	"kr":   RegionKorea,
+	"lan":  RegionLatinAmericaNorth,
+	"las":  RegionLatinAmericaSouth,
+	"me":   RegionMiddleEast,
+	"na":   RegionNorthAmerica,
+	"oce":  RegionOceania,
+	"ph":   RegionPhilippines,
+	"ru":   RegionRussia,
+	"sg":   RegionSingapore,
+	"th":   RegionThailand,
+	"tr":   RegionTurkey,
+	"tw":   RegionTaiwan,
+	"vn":   RegionVietnam,
+}
+
+// ParseRegion accepts either a platform ("euw1") or its common name ("euw").
+func ParseRegion(s string) (Region, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if region, ok := regionAliases[s]; ok {
+		return region, nil
+	}
+
+	if _, ok := regionRoutes[Region(s)]; ok {
+		return Region(s), nil
+	}
+
+	return "", fmt.Errorf("unknown region %s", s)
+}
+
+// Platform is the host prefix for platform APIs such as summoner-v4.
+func (r Region) Platform() string {
+	return string(r)
+}
+
+// Route is the host prefix for regional APIs such as match-v5.
+func (r Region) Route() string {
+	return regionRoutes[r]
+}
+
+// Cluster is the host prefix for account-v1, which is not served from the
+// SEA route.
+func (r Region) Cluster() string {
+	if route := r.Route(); route != "sea" {
+		return route
+	}
+
+	return "asia"
+}
+
+func (r Region) String() string {
+	return string(r)
+}
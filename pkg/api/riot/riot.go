@@ -3,22 +3,25 @@ package riot
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
-	"strconv"
-	"time"
+	"net/url"
 
+	"github.com/haydenheroux/lolscout/pkg/api/transport"
 	"github.com/sirupsen/logrus"
 )
 
 type client struct {
-	APIKey string
+	APIKey    string
+	Region    Region
+	transport transport.Transport
 }
 
-func CreateClient(apiKey string) client {
+func CreateClient(apiKey string, region Region, t transport.Transport) client {
 	return client{
-		APIKey: apiKey,
+		APIKey:    apiKey,
+		Region:    region,
+		transport: t,
 	}
 }
 
@@ -28,56 +31,55 @@ type Account struct {
 	TagLine  string `json:"tagLine"`
 }
 
+// RiotID is the account's Riot ID.
+func (a Account) RiotID() RiotID {
+	return RiotID{GameName: a.GameName, TagLine: a.TagLine}
+}
+
 type getter struct {
-	client   client
-	gameName string
-	tagLine  string
+	client client
+	id     RiotID
 }
 
-func (c client) Get(gameName, tagLine string) getter {
+func (c client) Get(id RiotID) getter {
 	return getter{
-		client:   c,
-		gameName: gameName,
-		tagLine:  tagLine,
+		client: c,
+		id:     id,
 	}
 }
 
+var ErrNotFound = errors.New("user not found")
+
 func (g getter) Account() (*Account, error) {
-	url := fmt.Sprintf("https://americas.api.riotgames.com/riot/account/v1/accounts/by-riot-id/%s/%s", g.gameName, g.tagLine)
+	return g.client.account("/riot/account/v1/accounts/by-riot-id/" + g.id.EscapedPath())
+}
+
+// AccountByPUUID finds an account's current Riot ID.
+func (c client) AccountByPUUID(puuid string) (*Account, error) {
+	return c.account("/riot/account/v1/accounts/by-puuid/" + url.PathEscape(puuid))
+}
+
+func (c client) account(endpoint string) (*Account, error) {
+	url := c.transport.RiotURL(c.Region.Cluster(), endpoint)
 
-	client := &http.Client{}
+	client := c.transport.RiotClient()
 
 	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("X-Riot-Token", g.client.APIKey)
+	req.Header.Set("X-Riot-Token", c.APIKey)
 
 	res, err := client.Do(req)
 	if err != nil {
 		return &Account{}, err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		if res.StatusCode == http.StatusForbidden {
 			return &Account{}, errors.New("possibly bad riot api key")
 		}
 
-		if res.StatusCode == http.StatusTooManyRequests {
-			retry := res.Header.Get("Retry-After")
-			seconds, err := strconv.Atoi(retry)
-
-			if err != nil {
-				logrus.Debug(err)
-				return nil, err
-			}
-
-			logrus.Infof("rate limited, waiting %d seconds", seconds)
-
-			time.Sleep(time.Duration(seconds) * time.Second)
-
-			return g.Account()
-		}
-
 		if res.StatusCode == http.StatusNotFound {
-			return &Account{}, errors.New("user not found")
+			return &Account{}, ErrNotFound
 		}
 
 		logrus.Infof("status code %d", res.StatusCode)
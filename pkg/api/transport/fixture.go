@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+type fixture struct {
+	Method     string          `json:"method"`
+	URL        string          `json:"url"`
+	StatusCode int             `json:"statusCode"`
+	Header     http.Header     `json:"header"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	// Used instead of Body when the response is not JSON
+	Text string `json:"text,omitempty"`
+}
+
+// Query parameters bounding a time window relative to now, which would keep
+// a recorded scan from ever being replayed
+var windowParams = []string{"startTime", "endTime"}
+
+// fixturePath names a request's fixture by its method, URL and body, so
+// credentials in headers are never part of it.
+func fixturePath(dir string, req *http.Request) (string, error) {
+	var body []byte
+
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+
+		body, err = io.ReadAll(reader)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	url := *req.URL
+	query := url.Query()
+
+	for _, param := range windowParams {
+		query.Del(param)
+	}
+
+	url.RawQuery = query.Encode()
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, &url)
+	hash.Write(body)
+
+	return filepath.Join(dir, hex.EncodeToString(hash.Sum(nil))[:16]+".json"), nil
+}
+
+type recorder struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, err := fixturePath(r.dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Rate limits and outages would only be replayed as failures
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return res, nil
+	}
+
+	f := fixture{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+	}
+
+	f.Header.Del("Set-Cookie")
+
+	if json.Valid(body) {
+		f.Body = body
+	} else {
+		f.Text = string(body)
+	}
+
+	contents, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type replayer struct {
+	dir string
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, err := fixturePath(r.dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no fixture for %s %s", req.Method, req.URL)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var f fixture
+
+	if err := json.Unmarshal(contents, &f); err != nil {
+		return nil, fmt.Errorf("bad fixture %s: %w", path, err)
+	}
+
+	body := []byte(f.Body)
+
+	if len(body) == 0 {
+		body = []byte(f.Text)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
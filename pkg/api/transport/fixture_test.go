@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFixturePath(t *testing.T) {
+	const ids = "http://127.0.0.1/americas/lol/match/v5/matches/by-puuid/p0/ids"
+
+	tests := []struct {
+		name  string
+		a     string
+		b     string
+		equal bool
+	}{
+		{"same", ids + "?count=20&queue=420", ids + "?count=20&queue=420", true},
+		{"different end time", ids + "?endTime=1700000000&queue=420", ids + "?endTime=1700086400&queue=420", true},
+		{"different window", ids + "?startTime=1&endTime=2&queue=420", ids + "?startTime=3&queue=420", true},
+		{"different queue", ids + "?endTime=1700000000&queue=420", ids + "?endTime=1700000000&queue=440", false},
+		{"different path", ids, strings.Replace(ids, "p0", "p1", 1), false},
+	}
+
+	for _, test := range tests {
+		a, err := http.NewRequest(http.MethodGet, test.a, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := http.NewRequest(http.MethodGet, test.b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pathA, err := fixturePath("fixtures", a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pathB, err := fixturePath("fixtures", b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (pathA == pathB) != test.equal {
+			t.Errorf("%s: %s and %s, want equal %t", test.name, pathA, pathB, test.equal)
+		}
+	}
+}
+
+func TestFixturePathBody(t *testing.T) {
+	const graphql = "http://127.0.0.1/graphql"
+
+	a, err := http.NewRequest(http.MethodPost, graphql, strings.NewReader(`{"operationName":"getAllLeagueTeams"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := http.NewRequest(http.MethodPost, graphql, strings.NewReader(`{"operationName":"teamRoster"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pathA, err := fixturePath("fixtures", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pathB, err := fixturePath("fixtures", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pathA == pathB {
+		t.Errorf("requests with different bodies share fixture %s", pathA)
+	}
+}
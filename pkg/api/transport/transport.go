@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/haydenheroux/lolscout/pkg/api/ratelimit"
+)
+
+const (
+	DefaultRiotBaseURL       = "https://%s.api.riotgames.com"
+	DefaultPlayVSBaseURL     = "https://api.playvs.com"
+	DefaultDataDragonBaseURL = "https://ddragon.leagueoflegends.com"
+)
+
+const riotHostSuffix = ".api.riotgames.com"
+
+// Transport is where the API clients send their requests.
+type Transport struct {
+	// Format of Riot API base URLs, with %s for the platform or route
+	RiotBaseURL       string
+	PlayVSBaseURL     string
+	DataDragonBaseURL string
+	RoundTripper      http.RoundTripper
+	limiter           *ratelimit.Limiter
+}
+
+func Default() Transport {
+	return Transport{
+		RiotBaseURL:       DefaultRiotBaseURL,
+		PlayVSBaseURL:     DefaultPlayVSBaseURL,
+		DataDragonBaseURL: DefaultDataDragonBaseURL,
+		RoundTripper:      http.DefaultTransport,
+	}
+}
+
+// Record saves every response into dir as it is received.
+func (t Transport) Record(dir string) Transport {
+	t.RoundTripper = &recorder{dir: dir, next: t.RoundTripper}
+
+	return t
+}
+
+// Replay serves responses saved by Record instead of sending requests.
+func (t Transport) Replay(dir string) Transport {
+	t.RoundTripper = &replayer{dir: dir}
+
+	return t
+}
+
+// RateLimited shares one rate limiter between every Riot API client using the
+// transport. It should be the last change made to the transport.
+func (t Transport) RateLimited() Transport {
+	t.limiter = ratelimit.New(t.RoundTripper)
+
+	return t
+}
+
+func (t Transport) RiotURL(host, endpoint string) string {
+	return fmt.Sprintf(t.RiotBaseURL, host) + endpoint
+}
+
+func (t Transport) PlayVSURL(endpoint string) string {
+	return t.PlayVSBaseURL + endpoint
+}
+
+func (t Transport) DataDragonURL(endpoint string) string {
+	return t.DataDragonBaseURL + endpoint
+}
+
+func (t Transport) Client() *http.Client {
+	return &http.Client{
+		Transport: t.RoundTripper,
+	}
+}
+
+// RiotClient is an HTTP client for the Riot API. Requests built by libraries
+// that hardcode the Riot API hosts are redirected to the Riot base URL.
+func (t Transport) RiotClient() *http.Client {
+	var next http.RoundTripper = t.RoundTripper
+
+	if t.limiter != nil {
+		next = t.limiter
+	}
+
+	return &http.Client{
+		Transport: &rewriter{baseURL: t.RiotBaseURL, next: next},
+	}
+}
+
+type rewriter struct {
+	baseURL string
+	next    http.RoundTripper
+}
+
+func (r *rewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	host, ok := strings.CutSuffix(req.URL.Host, riotHostSuffix)
+
+	if ok && r.baseURL != DefaultRiotBaseURL {
+		url, err := url.Parse(fmt.Sprintf(r.baseURL, host) + req.URL.RequestURI())
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.URL = url
+		req.Host = ""
+	}
+
+	return r.next.RoundTrip(req)
+}
@@ -1,10 +1,23 @@
 package db
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/KnutZuidema/golio/riot/lol"
 	"github.com/haydenheroux/lolscout/pkg/analytics"
+	lolApi "github.com/haydenheroux/lolscout/pkg/api/lol"
+	"github.com/haydenheroux/lolscout/pkg/api/riot"
 	"github.com/haydenheroux/lolscout/pkg/model"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -21,7 +34,7 @@ func CreateClient(dsn string) (*client, error) {
 		return &client{}, err
 	}
 
-	err = db.AutoMigrate(&model.Team{}, &model.Player{}, &model.MatchMetrics{})
+	err = db.AutoMigrate(&model.Team{}, &model.Player{}, &model.MatchMetrics{}, &model.MatchPayload{}, &model.ScanCursor{}, &model.RiotID{}, &model.RankSnapshot{}, &model.ChampionMastery{}, &model.Match{}, &model.Participant{}, &model.Ban{}, &model.TeamGame{})
 
 	if err != nil {
 		return &client{}, err
@@ -54,12 +67,100 @@ func (dbc client) CreateOrUpdatePlayer(player *model.Player) error {
 	return dbc.DB.Save(player).Error
 }
 
-func (dbc client) GetPlayerByNameTag(gameName, tagLine string) (*model.Player, error) {
-	var player model.Player
-	if err := dbc.DB.Model(&model.Player{}).Preload("PlayerMetrics").First(&player, "game_name = ? AND tag_line = ?", gameName, tagLine).Error; err != nil {
+func (dbc client) GetAllPlayers() ([]*model.Player, error) {
+	var players []*model.Player
+	if err := dbc.DB.Find(&players).Error; err != nil {
 		return nil, err
 	}
-	return &player, nil
+	return players, nil
+}
+
+// GetPlayerByRiotID finds a player by their current Riot ID or, failing
+// that, by the Riot ID they were most recently seen with. Riot IDs are
+// matched ignoring case.
+func (dbc client) GetPlayerByRiotID(id riot.RiotID) (*model.Player, error) {
+	var players []*model.Player
+	if err := dbc.whereRiotID(id).Select("puuid", "game_name", "tag_line").Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	for _, player := range players {
+		if id.Equal(riot.RiotID{GameName: player.GameName, TagLine: player.TagLine}) {
+			return dbc.GetPlayerByPUUID(player.PUUID)
+		}
+	}
+
+	var riotIds []*model.RiotID
+	if err := dbc.whereRiotID(id).Order("last_seen desc").Find(&riotIds).Error; err != nil {
+		return nil, err
+	}
+
+	for _, riotId := range riotIds {
+		if id.Equal(riot.RiotID{GameName: riotId.GameName, TagLine: riotId.TagLine}) {
+			return dbc.GetPlayerByPUUID(riotId.PUUID)
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// whereRiotID narrows a search to the rows that could match a Riot ID, which
+// are then compared exactly. Case folding keeps the number of characters, and
+// SQLite only folds ASCII, so rows with other characters are kept for an
+// ASCII Riot ID.
+func (dbc client) whereRiotID(id riot.RiotID) *gorm.DB {
+	query := dbc.DB.Where("length(game_name) = ? AND length(tag_line) = ?", utf8.RuneCountInString(id.GameName), utf8.RuneCountInString(id.TagLine))
+
+	if isASCII(id.GameName + id.TagLine) {
+		query = query.Where("((lower(game_name) = lower(?) AND lower(tag_line) = lower(?)) OR game_name || tag_line GLOB ?)", id.GameName, id.TagLine, "*[^ -~]*")
+	}
+
+	return query
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (dbc client) RenamePlayer(puuid string, id riot.RiotID) error {
+	return dbc.DB.Model(&model.Player{}).Where("puuid = ?", puuid).Updates(map[string]interface{}{
+		"game_name": id.GameName,
+		"tag_line":  id.TagLine,
+	}).Error
+}
+
+// RecordRiotID adds a Riot ID to a player's history, widening the dates it
+// was seen between if it is already known.
+func (dbc client) RecordRiotID(riotId *model.RiotID) error {
+	var existing model.RiotID
+	err := dbc.DB.First(&existing, "puuid = ? AND game_name = ? AND tag_line = ?", riotId.PUUID, riotId.GameName, riotId.TagLine).Error
+	if err == nil {
+		if existing.FirstSeen.Before(riotId.FirstSeen) {
+			riotId.FirstSeen = existing.FirstSeen
+		}
+
+		if existing.LastSeen.After(riotId.LastSeen) {
+			riotId.LastSeen = existing.LastSeen
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return dbc.DB.Save(riotId).Error
+}
+
+func (dbc client) GetRiotIDs(puuid string) ([]*model.RiotID, error) {
+	var riotIds []*model.RiotID
+	if err := dbc.DB.Order("last_seen desc").Find(&riotIds, "puuid = ?", puuid).Error; err != nil {
+		return nil, err
+	}
+	return riotIds, nil
 }
 
 func (dbc client) GetPlayerByPUUID(puuid string) (*model.Player, error) {
@@ -70,6 +171,56 @@ func (dbc client) GetPlayerByPUUID(puuid string) (*model.Player, error) {
 	return &player, nil
 }
 
+func (dbc client) UpdateMatchMetrics(metrics *model.MatchMetrics) error {
+	return dbc.DB.Save(metrics).Error
+}
+
+func (dbc client) GetAllMatchMetrics() ([]model.MatchMetrics, error) {
+	var metrics []model.MatchMetrics
+	if err := dbc.DB.Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// GetOutdatedMatchMetrics gets metrics derived by a version older than the
+// given version whose match is stored, so that they can be derived again.
+func (dbc client) GetOutdatedMatchMetrics(version int) ([]model.MatchMetrics, error) {
+	var metrics []model.MatchMetrics
+	if err := dbc.DB.Where("(version IS NULL OR version < ?) AND match_id IN (?)", version, dbc.DB.Model(&model.MatchPayload{}).Select("match_id")).Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// CountMatchMetricsBeforeVersion counts the metrics derived by a version
+// older than the given one, stored match or not.
+func (dbc client) CountMatchMetricsBeforeVersion(version int) (int64, error) {
+	var count int64
+	if err := dbc.DB.Model(&model.MatchMetrics{}).Where("version IS NULL OR version < ?", version).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CreateBaselineMatchMetrics saves baseline metrics, skipping those of
+// participants whose metrics for the match are already saved.
+func (dbc client) CreateBaselineMatchMetrics(metrics []*model.MatchMetrics) (int, error) {
+	if len(metrics) == 0 {
+		return 0, nil
+	}
+
+	result := dbc.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&metrics)
+
+	return int(result.RowsAffected), result.Error
+}
+
+// ClaimBaselineMatchMetrics unmarks the baseline metrics of a player who is
+// now scanned.
+func (dbc client) ClaimBaselineMatchMetrics(puuid string) error {
+	return dbc.DB.Model(&model.MatchMetrics{}).Where("puuid = ? AND baseline", puuid).Update("baseline", false).Error
+}
+
 func (dbc client) GetMatchIDsForPUUID(puuid string) ([]string, error) {
 	var matchMetrics []model.MatchMetrics
 
@@ -86,6 +237,296 @@ func (dbc client) GetMatchIDsForPUUID(puuid string) ([]string, error) {
 	return matchIDs, nil
 }
 
+func (dbc client) CreateOrUpdateScanCursor(cursor *model.ScanCursor) error {
+	return dbc.DB.Save(cursor).Error
+}
+
+func (dbc client) GetScanCursor(puuid string) (*model.ScanCursor, error) {
+	var cursor model.ScanCursor
+	if err := dbc.DB.First(&cursor, "puuid = ?", puuid).Error; err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func (dbc client) DeleteScanCursor(puuid string) error {
+	return dbc.DB.Delete(&model.ScanCursor{}, "puuid = ?", puuid).Error
+}
+
+// CreateRankSnapshot saves a player's standing unless it is the same as
+// their latest one in the queue.
+func (dbc client) CreateRankSnapshot(snapshot *model.RankSnapshot) error {
+	var latest model.RankSnapshot
+	err := dbc.DB.Order("created_at desc").First(&latest, "puuid = ? AND queue_type = ?", snapshot.PUUID, snapshot.QueueType).Error
+	if err == nil && latest.Same(*snapshot) {
+		return nil
+	}
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return dbc.DB.Create(snapshot).Error
+}
+
+// GetLatestRankSnapshot returns a player's latest standing in a queue, or
+// nil if they have never been scanned.
+func (dbc client) GetLatestRankSnapshot(puuid, queueType string) (*model.RankSnapshot, error) {
+	var snapshots []*model.RankSnapshot
+	if err := dbc.DB.Order("created_at desc").Limit(1).Find(&snapshots, "puuid = ? AND queue_type = ?", puuid, queueType).Error; err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+
+	return snapshots[0], nil
+}
+
+func (dbc client) CreateOrUpdateChampionMasteries(masteries []*model.ChampionMastery) error {
+	if len(masteries) == 0 {
+		return nil
+	}
+
+	return dbc.DB.Save(&masteries).Error
+}
+
+func (dbc client) GetChampionMasteries(puuid string) ([]*model.ChampionMastery, error) {
+	var masteries []*model.ChampionMastery
+	if err := dbc.DB.Order("points desc").Find(&masteries, "puuid = ?", puuid).Error; err != nil {
+		return nil, err
+	}
+	return masteries, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var compressed bytes.Buffer
+
+	w := gzip.NewWriter(&compressed)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return compressed.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func (dbc client) CreateOrUpdateMatchPayload(matchId string, payload, timeline []byte) error {
+	compressedPayload, err := compress(payload)
+	if err != nil {
+		return err
+	}
+
+	compressedTimeline, err := compress(timeline)
+	if err != nil {
+		return err
+	}
+
+	return dbc.DB.Save(&model.MatchPayload{
+		MatchID:  matchId,
+		Payload:  compressedPayload,
+		Timeline: compressedTimeline,
+	}).Error
+}
+
+func (dbc client) GetMatchPayloadIDs() ([]string, error) {
+	var matchIds []string
+	if err := dbc.DB.Model(&model.MatchPayload{}).Order("match_id").Pluck("match_id", &matchIds).Error; err != nil {
+		return nil, err
+	}
+	return matchIds, nil
+}
+
+// GetStoredMatchIDs gets which of the given matches are already stored.
+func (dbc client) GetStoredMatchIDs(matchIds []string) ([]string, error) {
+	var storedMatchIds []string
+	if len(matchIds) == 0 {
+		return storedMatchIds, nil
+	}
+	if err := dbc.DB.Model(&model.MatchPayload{}).Where("match_id IN ?", matchIds).Pluck("match_id", &storedMatchIds).Error; err != nil {
+		return nil, err
+	}
+	return storedMatchIds, nil
+}
+
+// GetMatchPayloadIDsWithoutMatch gets the IDs of stored matches that have
+// not been saved as a Match.
+func (dbc client) GetMatchPayloadIDsWithoutMatch() ([]string, error) {
+	var matchIds []string
+	if err := dbc.DB.Model(&model.MatchPayload{}).Where("match_id NOT IN (?)", dbc.DB.Model(&model.Match{}).Select("match_id")).Order("match_id").Pluck("match_id", &matchIds).Error; err != nil {
+		return nil, err
+	}
+	return matchIds, nil
+}
+
+// CreateOrUpdateMatch saves a match along with its participants and bans.
+func (dbc client) CreateOrUpdateMatch(match *model.Match) error {
+	return dbc.DB.Session(&gorm.Session{FullSaveAssociations: true}).Save(match).Error
+}
+
+func (dbc client) GetMatchByID(matchId string) (*model.Match, error) {
+	var match model.Match
+	if err := dbc.DB.Preload("Participants").Preload("Bans").Preload("Metrics").First(&match, "match_id = ?", matchId).Error; err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// DetectTeamGames records the saved matches in which at least
+// model.TeamGamePlayers players of a team played on the same side, and
+// returns how many were found.
+func (dbc client) DetectTeamGames(team *model.Team) (int, error) {
+	var puuids []string
+
+	for _, player := range team.Players {
+		puuids = append(puuids, player.PUUID)
+	}
+
+	if len(puuids) == 0 {
+		return 0, nil
+	}
+
+	var sides []struct {
+		MatchID string
+		TeamID  int
+		Members string
+		Win     bool
+	}
+
+	if err := dbc.DB.Model(&model.Participant{}).
+		Select("match_id, team_id, group_concat(puuid) AS members, max(win) AS win").
+		Where("puuid IN ?", puuids).
+		Group("match_id, team_id").
+		Having("count(*) >= ?", model.TeamGamePlayers).
+		Scan(&sides).Error; err != nil {
+		return 0, err
+	}
+
+	if len(sides) == 0 {
+		return 0, nil
+	}
+
+	// The team played both sides of an in-house game
+	sidesByMatch := make(map[string][]int)
+
+	for _, side := range sides {
+		sidesByMatch[side.MatchID] = append(sidesByMatch[side.MatchID], side.TeamID)
+	}
+
+	games := make([]*model.TeamGame, len(sides))
+
+	for i, side := range sides {
+		members := strings.Split(side.Members, ",")
+		sort.Strings(members)
+
+		var opponentSide int
+
+		for _, other := range sidesByMatch[side.MatchID] {
+			if other != side.TeamID {
+				opponentSide = other
+			}
+		}
+
+		games[i] = &model.TeamGame{
+			TeamID:       team.ID,
+			MatchID:      side.MatchID,
+			Side:         side.TeamID,
+			OpponentSide: opponentSide,
+			Win:          side.Win,
+			Members:      strings.Join(members, ","),
+		}
+	}
+
+	if err := dbc.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&games).Error; err != nil {
+		return 0, err
+	}
+
+	return len(games), nil
+}
+
+// GetTeamGames gets the team games of a team with their matches, newest
+// first.
+func (dbc client) GetTeamGames(teamId string) ([]*model.TeamGame, error) {
+	var games []*model.TeamGame
+	if err := dbc.DB.Preload("Match.Participants").
+		Joins("JOIN matches ON matches.match_id = team_games.match_id").
+		Where("team_games.team_id = ?", teamId).
+		Order("matches.start_time DESC, team_games.side").
+		Find(&games).Error; err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+func (dbc client) GetMatchPayload(matchId string) ([]byte, error) {
+	var matchPayload model.MatchPayload
+	if err := dbc.DB.Select("match_id", "payload").First(&matchPayload, "match_id = ?", matchId).Error; err != nil {
+		return nil, err
+	}
+
+	return decompress(matchPayload.Payload)
+}
+
+func (dbc client) GetMatch(matchId string) (*lol.Match, error) {
+	payload, err := dbc.GetMatchPayload(matchId)
+	if err != nil {
+		return nil, err
+	}
+
+	var match lol.Match
+
+	if err := json.Unmarshal(payload, &match); err != nil {
+		return nil, err
+	}
+
+	return &match, nil
+}
+
+// GetTimeline returns the stored timeline of a match, or nil if the match
+// was stored without one.
+func (dbc client) GetTimeline(matchId string) (*lolApi.Timeline, error) {
+	var matchPayload model.MatchPayload
+	if err := dbc.DB.Select("match_id", "timeline").First(&matchPayload, "match_id = ?", matchId).Error; err != nil {
+		return nil, err
+	}
+
+	if len(matchPayload.Timeline) == 0 {
+		return nil, nil
+	}
+
+	payload, err := decompress(matchPayload.Timeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeline lolApi.Timeline
+
+	if err := json.Unmarshal(payload, &timeline); err != nil {
+		return nil, err
+	}
+
+	return &timeline, nil
+}
+
 func (dbc client) GetMetricsForPosition(position model.Position) ([]model.MatchMetrics, error) {
 	var metrics []model.MatchMetrics
 
@@ -106,6 +547,34 @@ func (dbc client) GetMetricsForChampion(champion model.Champion) ([]model.MatchM
 	return metrics, nil
 }
 
+// GetBaselineMetricsForPosition gets the baseline metrics for a position,
+// leaving out the given players.
+func (dbc client) GetBaselineMetricsForPosition(position model.Position, excluded []string) ([]model.MatchMetrics, error) {
+	return dbc.getBaselineMetrics(dbc.DB.Where("position = ?", position), excluded)
+}
+
+// GetBaselineMetricsForChampion gets the baseline metrics for a champion,
+// leaving out the given players.
+func (dbc client) GetBaselineMetricsForChampion(champion model.Champion, excluded []string) ([]model.MatchMetrics, error) {
+	return dbc.getBaselineMetrics(dbc.DB.Where("champion = ?", champion), excluded)
+}
+
+func (dbc client) getBaselineMetrics(query *gorm.DB, excluded []string) ([]model.MatchMetrics, error) {
+	var metrics []model.MatchMetrics
+
+	query = query.Model(&model.MatchMetrics{}).Where("baseline")
+
+	if len(excluded) > 0 {
+		query = query.Where("puuid NOT IN ?", excluded)
+	}
+
+	if err := query.Find(&metrics).Error; err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
 func (dbc client) GetAnalyticsByPosition() (map[model.Position]*analytics.Analytics, error) {
 	result := make(map[model.Position]*analytics.Analytics)
 
@@ -115,7 +584,7 @@ func (dbc client) GetAnalyticsByPosition() (map[model.Position]*analytics.Analyt
 			return result, err
 		}
 
-		result[position] = analytics.Analyze(positionMetrics)
+		result[position] = analytics.Analyze(analytics.ForMatchType(positionMetrics, model.MatchTypeSummonersRift))
 	}
 
 	return result, nil
@@ -146,7 +615,7 @@ func (dbc client) GetAnalyticsByChampion() (map[model.Champion]*analytics.Analyt
 			return result, err
 		}
 
-		result[champion] = analytics.Analyze(championMetrics)
+		result[champion] = analytics.Analyze(analytics.ForMatchType(championMetrics, model.MatchTypeSummonersRift))
 	}
 
 	return result, nil
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/haydenheroux/lolscout/pkg/model"
+)
+
+// createMatch saves a match in which the PUUIDs play on blue side and then red
+// side, in order.
+func createMatch(t *testing.T, dbc *client, matchId string, startTime time.Time, puuids ...string) {
+	match := &model.Match{MatchID: matchId, StartTime: startTime, WinningTeam: 100}
+
+	for i, puuid := range puuids {
+		teamId := 100
+		if i >= 5 {
+			teamId = 200
+		}
+
+		match.Participants = append(match.Participants, model.Participant{
+			MatchID:       matchId,
+			ParticipantID: i + 1,
+			PUUID:         puuid,
+			TeamID:        teamId,
+			Win:           teamId == 100,
+		})
+	}
+
+	if err := dbc.CreateOrUpdateMatch(match); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectTeamGames(t *testing.T) {
+	dbc, err := CreateClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teamId := "t1"
+	team := &model.Team{ID: teamId, Name: "Team One"}
+
+	for i := 0; i < 6; i++ {
+		team.Players = append(team.Players, model.Player{PUUID: fmt.Sprintf("p%d", i), TeamID: &teamId})
+	}
+
+	if err := dbc.CreateOrUpdateTeam(team); err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Three players on blue side against strangers
+	createMatch(t, dbc, "NA1_1", t0, "p0", "p1", "p2", "x0", "x1", "y0", "y1", "y2", "y3", "y4")
+	// An in-house game, with three players on each side
+	createMatch(t, dbc, "NA1_2", t0.Add(time.Hour), "p0", "p1", "p2", "x0", "x1", "p3", "p4", "p5", "y0", "y1")
+	// Only two players on each side
+	createMatch(t, dbc, "NA1_3", t0.Add(2*time.Hour), "p0", "p1", "x0", "x1", "x2", "p3", "p4", "y0", "y1", "y2")
+
+	found, err := dbc.DetectTeamGames(team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found != 3 {
+		t.Errorf("found %d team games, want 3", found)
+	}
+
+	games, err := dbc.GetTeamGames(teamId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.TeamGame{
+		{MatchID: "NA1_2", Side: 100, OpponentSide: 200, Win: true, Members: "p0,p1,p2"},
+		{MatchID: "NA1_2", Side: 200, OpponentSide: 100, Win: false, Members: "p3,p4,p5"},
+		{MatchID: "NA1_1", Side: 100, OpponentSide: 0, Win: true, Members: "p0,p1,p2"},
+	}
+
+	if len(games) != len(want) {
+		t.Fatalf("got %d team games, want %d", len(games), len(want))
+	}
+
+	for i, game := range games {
+		got := model.TeamGame{MatchID: game.MatchID, Side: game.Side, OpponentSide: game.OpponentSide, Win: game.Win, Members: game.Members}
+
+		if got != want[i] {
+			t.Errorf("team game %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +23,7 @@ type Player struct {
 	PUUID         string         `gorm:"primaryKey;column:puuid"`
 	GameName      string         `gorm:"column:game_name"`
 	TagLine       string         `gorm:"column:tag_line"`
+	Region        string         `gorm:"column:region"`
 	TeamID        *string        `gorm:"column:team_id"`
 	CreatedAt     time.Time      `gorm:"column:created_at"`
 	UpdatedAt     time.Time      `gorm:"column:updated_at"`
@@ -27,6 +31,16 @@ type Player struct {
 	PlayerMetrics []MatchMetrics `gorm:"foreignKey:puuid"`
 }
 
+// RiotID is a Riot ID that a player has used. Players keep their PUUID when
+// they rename, so every Riot ID they have been seen with is kept.
+type RiotID struct {
+	PUUID     string    `gorm:"primaryKey;column:puuid"`
+	GameName  string    `gorm:"primaryKey;column:game_name"`
+	TagLine   string    `gorm:"primaryKey;column:tag_line"`
+	FirstSeen time.Time `gorm:"column:first_seen"`
+	LastSeen  time.Time `gorm:"column:last_seen"`
+}
+
 func (p *Player) AppendMatchMetrics(matchMetrics []*MatchMetrics) int {
 	count := 0
 
@@ -60,6 +74,8 @@ func contains(s []string, e string) bool {
 	return false
 }
 
+// MatchMetrics is a player's performance in a match. Damage dealt is damage
+// dealt to champions.
 type MatchMetrics struct {
 	gorm.Model
 
@@ -67,27 +83,317 @@ type MatchMetrics struct {
 
 	MatchID string `gorm:"column:match_id;uniqueIndex:compositeIndex;"`
 
+	// Set for metrics of the other participants in a scanned player's
+	// matches, kept as a population to compare against
+	Baseline bool `gorm:"column:baseline;index"`
+
+	// Version of the derivation of the metrics; metrics of an older version
+	// are derived again from the stored match
+	Version int `gorm:"column:version"`
+
 	StartTime time.Time
 
-	Assists              int
-	CS                   int
-	CSPerMinute          float64
-	Champion             Champion
-	ControlWardsPlaced   int
-	DamageDealt          int
-	DamageDealtPerMinute float64
-	DamageDealtShare     float64
-	Deaths               int
-	DurationMinutes      float64
-	KillParticipation    float64
-	Kills                int
-	Level                int
-	MatchType            MatchType
-	Position             Position
-	TurretsTaken         int
-	WardsKilled          int
-	WardsPlaced          int
-	Win                  bool
+	Assists                  int
+	CS                       int
+	CSPerMinute              float64
+	Champion                 Champion
+	ControlWardsPlaced       int
+	DamageDealt              int
+	DamageDealtPerMinute     float64
+	DamageDealtShare         float64
+	DamageMitigated          int
+	DamageMitigatedPerMinute float64
+	DamageTaken              int
+	DamageTakenPerMinute     float64
+	DamageTakenShare         float64
+	Deaths                   int
+	DurationMinutes          float64
+	KillParticipation        float64
+	Kills                    int
+	Level                    int
+	MagicDamageDealt         int
+	MatchType                MatchType
+	Patch                    string
+	PhysicalDamageDealt      int
+	Position                 Position
+	QueueID                  int
+	TrueDamageDealt          int
+	TurretsTaken             int
+	WardsKilled              int
+	WardsPlaced              int
+	Win                      bool
+
+	// Minutes of the match covered by its timeline; zero if there is no timeline.
+	TimelineMinutes int
+	CSAt10          int
+	CSAt15          int
+	CSDiffAt10      int
+	CSDiffAt15      int
+	GoldAt10        int
+	GoldAt15        int
+	GoldDiffAt10    int
+	GoldDiffAt15    int
+	XPAt10          int
+	XPAt15          int
+	XPDiffAt10      int
+	XPDiffAt15      int
+
+	// PUUID of the enemy in the same position, or empty if there is none, in
+	// which case there are no differentials.
+	OpponentPUUID         string `gorm:"column:opponent_puuid"`
+	CSDiff                int
+	DamageDiff            int
+	GoldDiff              int
+	KillParticipationDiff float64
+	LevelDiff             int
+
+	// Comma-separated item IDs of the final inventory, without the trinket
+	Items         string
+	Trinket       int
+	PrimaryTree   int
+	Keystone      int
+	SecondaryTree int
+	Spell1        int
+	Spell2        int
+}
+
+// ItemIDs are the item IDs of the final inventory, without empty slots.
+func (m MatchMetrics) ItemIDs() []int {
+	var ids []int
+
+	for _, s := range strings.Split(m.Items, ",") {
+		if id, err := strconv.Atoi(s); err == nil && id != 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// Diff returns the names of the derived fields that differ between two
+// metrics for the same match.
+func (m MatchMetrics) Diff(other MatchMetrics) []string {
+	var fields []string
+
+	a := reflect.ValueOf(m)
+	b := reflect.ValueOf(other)
+
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+
+		switch field.Name {
+		case "Model", "PUUID", "MatchID", "Baseline", "Version":
+			continue
+		}
+
+		x := a.Field(i).Interface()
+		y := b.Field(i).Interface()
+
+		if t, ok := x.(time.Time); ok {
+			if !t.Equal(y.(time.Time)) {
+				fields = append(fields, field.Name)
+			}
+
+			continue
+		}
+
+		if !reflect.DeepEqual(x, y) {
+			fields = append(fields, field.Name)
+		}
+	}
+
+	return fields
+}
+
+// Match is a match and everyone who played in it, kept alongside the
+// metrics of the players who were scanned.
+type Match struct {
+	MatchID         string    `gorm:"primaryKey;column:match_id"`
+	CreatedAt       time.Time `gorm:"column:created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at"`
+	StartTime       time.Time `gorm:"column:start_time"`
+	DurationMinutes float64   `gorm:"column:duration_minutes"`
+	QueueID         int       `gorm:"column:queue_id"`
+	MatchType       MatchType `gorm:"column:match_type"`
+	Patch           string    `gorm:"column:patch"`
+	// 100 for blue side or 200 for red side
+	WinningTeam  int           `gorm:"column:winning_team"`
+	Participants []Participant `gorm:"foreignKey:MatchID;references:MatchID"`
+	Bans         []Ban         `gorm:"foreignKey:MatchID;references:MatchID"`
+	// Not a constraint, since metrics saved before matches were kept have no
+	// match
+	Metrics []MatchMetrics `gorm:"foreignKey:MatchID;references:MatchID;-:migration"`
+}
+
+// Participant is one of the players in a match, scanned or not.
+type Participant struct {
+	MatchID       string `gorm:"primaryKey;column:match_id"`
+	ParticipantID int    `gorm:"primaryKey;column:participant_id"`
+	// Bots share the PUUID "BOT"
+	PUUID      string   `gorm:"index;column:puuid"`
+	GameName   string   `gorm:"column:game_name"`
+	TagLine    string   `gorm:"column:tag_line"`
+	TeamID     int      `gorm:"index;column:team_id"`
+	Champion   Champion `gorm:"column:champion"`
+	ChampionID int      `gorm:"column:champion_id"`
+	Position   Position `gorm:"column:position"`
+	Kills      int      `gorm:"column:kills"`
+	Deaths     int      `gorm:"column:deaths"`
+	Assists    int      `gorm:"column:assists"`
+	Win        bool     `gorm:"column:win"`
+}
+
+// Ban is a champion banned by a team in a match.
+type Ban struct {
+	MatchID    string `gorm:"primaryKey;column:match_id"`
+	TeamID     int    `gorm:"primaryKey;column:team_id"`
+	PickTurn   int    `gorm:"primaryKey;column:pick_turn"`
+	ChampionID int    `gorm:"column:champion_id"`
+}
+
+// TeamGamePlayers is how many players of a team must play on the same side
+// of a match for it to be a team game.
+const TeamGamePlayers = 3
+
+// TeamGame is a match in which players of a team played together, such as
+// practice or an official game. An in-house game is a team game for both
+// sides.
+type TeamGame struct {
+	TeamID  string `gorm:"primaryKey;column:team_id"`
+	MatchID string `gorm:"primaryKey;column:match_id"`
+	// 100 for blue side or 200 for red side
+	Side      int       `gorm:"primaryKey;column:side"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+	// The side the team also played in an in-house game, or 0 otherwise
+	OpponentSide int  `gorm:"column:opponent_side"`
+	Win          bool `gorm:"column:win"`
+	// Comma-separated PUUIDs of the team's players in the match
+	Members string `gorm:"column:members"`
+	Match   *Match `gorm:"foreignKey:MatchID;references:MatchID"`
+}
+
+// MemberPUUIDs is the PUUIDs of the team's players in the match.
+func (g TeamGame) MemberPUUIDs() []string {
+	if len(g.Members) == 0 {
+		return nil
+	}
+
+	return strings.Split(g.Members, ",")
+}
+
+// MatchPayload is the compressed match-v5 JSON of a match and its timeline,
+// kept so that metrics can be derived again without downloading the match.
+type MatchPayload struct {
+	MatchID   string    `gorm:"primaryKey;column:match_id"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+	Payload   []byte    `gorm:"column:payload"`
+	Timeline  []byte    `gorm:"column:timeline"`
+}
+
+// ScanCursor is the window of a player's scan that stopped early, kept so
+// that the scan can be resumed.
+type ScanCursor struct {
+	PUUID     string    `gorm:"primaryKey;column:puuid"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+	StartTime time.Time `gorm:"column:start_time"`
+	EndTime   time.Time `gorm:"column:end_time"`
+	// Comma-separated queue IDs
+	Queues string `gorm:"column:queues"`
+	// If positive, the scan was of the most recent matches before EndTime
+	// instead of a window
+	Last   int    `gorm:"column:last"`
+	Reason string `gorm:"column:reason"`
+}
+
+// RankSnapshot is a player's ranked standing in a queue when they were
+// scanned. A player who is not placed in the queue has no tier.
+type RankSnapshot struct {
+	ID           uint      `gorm:"primaryKey;column:id"`
+	PUUID        string    `gorm:"index;column:puuid"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	QueueType    string    `gorm:"column:queue_type"`
+	Tier         string    `gorm:"column:tier"`
+	Division     string    `gorm:"column:division"`
+	LeaguePoints int       `gorm:"column:league_points"`
+	Wins         int       `gorm:"column:wins"`
+	Losses       int       `gorm:"column:losses"`
+}
+
+// Same reports whether two snapshots are of the same standing.
+func (r RankSnapshot) Same(other RankSnapshot) bool {
+	return r.QueueType == other.QueueType && r.Tier == other.Tier && r.Division == other.Division &&
+		r.LeaguePoints == other.LeaguePoints && r.Wins == other.Wins && r.Losses == other.Losses
+}
+
+// Rank is the tier, division and LP, such as "Gold II 45 LP".
+func (r RankSnapshot) Rank() string {
+	if r.Tier == "" {
+		return "Unranked"
+	}
+
+	tier := strings.ToUpper(r.Tier[:1]) + strings.ToLower(r.Tier[1:])
+
+	switch r.Tier {
+	case "MASTER", "GRANDMASTER", "CHALLENGER":
+		return fmt.Sprintf("%s %d LP", tier, r.LeaguePoints)
+	default:
+		return fmt.Sprintf("%s %s %d LP", tier, r.Division, r.LeaguePoints)
+	}
+}
+
+func (r RankSnapshot) String() string {
+	if r.Tier == "" {
+		return r.Rank()
+	}
+
+	return fmt.Sprintf("%s (%dW %dL)", r.Rank(), r.Wins, r.Losses)
+}
+
+// ChampionMastery is a player's mastery of a champion across every game they
+// have played on it, not only the stored matches.
+type ChampionMastery struct {
+	PUUID        string    `gorm:"primaryKey;column:puuid"`
+	ChampionID   int       `gorm:"primaryKey;column:champion_id"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+	Champion     Champion  `gorm:"column:champion"`
+	Level        int       `gorm:"column:level"`
+	Points       int       `gorm:"column:points"`
+	LastPlayTime time.Time `gorm:"column:last_play_time"`
+}
+
+// PatchOf is the patch of a game version, such as "14.1" of "14.1.556.1234".
+func PatchOf(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+
+	if len(parts) < 2 {
+		return version
+	}
+
+	return parts[0] + "." + parts[1]
+}
+
+// ComparePatches orders patches by version, so that "14.10" is after "14.9".
+func ComparePatches(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+
+		if errX != nil || errY != nil {
+			return strings.Compare(a, b)
+		}
+
+		if x != y {
+			return x - y
+		}
+	}
+
+	return len(as) - len(bs)
 }
 
 type Champion string
@@ -100,12 +406,57 @@ type MatchType int
 
 const (
 	MatchTypeSummonersRift MatchType = iota
+	MatchTypeARAM
+	MatchTypeArena
+	MatchTypeURF
+	MatchTypeNexusBlitz
+	MatchTypeOneForAll
+	MatchTypeUltimateSpellbook
+	// Tutorials, the practice tool and rotating modes without their own type
+	MatchTypeOther
 )
 
+var MatchTypes = []MatchType{MatchTypeSummonersRift, MatchTypeARAM, MatchTypeArena, MatchTypeURF, MatchTypeNexusBlitz, MatchTypeOneForAll, MatchTypeUltimateSpellbook, MatchTypeOther}
+
+var matchTypeStrings = map[string]MatchType{
+	"rift":               MatchTypeSummonersRift,
+	"aram":               MatchTypeARAM,
+	"arena":              MatchTypeArena,
+	"urf":                MatchTypeURF,
+	"nexus-blitz":        MatchTypeNexusBlitz,
+	"one-for-all":        MatchTypeOneForAll,
+	"ultimate-spellbook": MatchTypeUltimateSpellbook,
+	"other":              MatchTypeOther,
+}
+
+// ParseMatchType accepts the name used to select a match type on the command
+// line, such as rift, aram or arena.
+func ParseMatchType(s string) (MatchType, error) {
+	if mt, ok := matchTypeStrings[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return mt, nil
+	}
+
+	return 0, fmt.Errorf("unknown mode %s", s)
+}
+
 func (mt MatchType) String() string {
 	switch mt {
 	case MatchTypeSummonersRift:
 		return "Summoner's Rift"
+	case MatchTypeARAM:
+		return "ARAM"
+	case MatchTypeArena:
+		return "Arena"
+	case MatchTypeURF:
+		return "URF"
+	case MatchTypeNexusBlitz:
+		return "Nexus Blitz"
+	case MatchTypeOneForAll:
+		return "One for All"
+	case MatchTypeUltimateSpellbook:
+		return "Ultimate Spellbook"
+	case MatchTypeOther:
+		return "Other"
 	default:
 		return ""
 	}
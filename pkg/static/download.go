@@ -0,0 +1,72 @@
+package static
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/haydenheroux/lolscout/pkg/api/transport"
+)
+
+func get(t transport.Transport, endpoint string) ([]byte, error) {
+	res, err := t.Client().Get(t.DataDragonURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get %s: %s", endpoint, res.Status)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// LatestVersion is the newest patch with Data Dragon data.
+func LatestVersion(t transport.Transport) (string, error) {
+	contents, err := get(t, "/api/versions.json")
+	if err != nil {
+		return "", err
+	}
+
+	var versions []string
+
+	if err := json.Unmarshal(contents, &versions); err != nil {
+		return "", err
+	}
+
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no data dragon versions")
+	}
+
+	return versions[0], nil
+}
+
+// Download saves the bundle of a patch into dir, replacing any bundle
+// already there.
+func Download(t transport.Transport, dir, version string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(dir, versionFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, file := range files {
+		contents, err := get(t, fmt.Sprintf("/cdn/%s/data/en_US/%s", version, file))
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, file), contents, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Written last, so that a partial download is not mistaken for a bundle
+	return os.WriteFile(filepath.Join(dir, versionFile), []byte(version+"\n"), 0644)
+}
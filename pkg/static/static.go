@@ -0,0 +1,361 @@
+package static
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	versionFile  = "version"
+	championFile = "champion.json"
+	itemFile     = "item.json"
+	runeFile     = "runesReforged.json"
+	spellFile    = "summoner.json"
+)
+
+var files = []string{championFile, itemFile, runeFile, spellFile}
+
+type Champion struct {
+	// Numeric ID used by the Riot API, such as 62
+	Key int
+	// Name used by match-v5, such as "MonkeyKing"
+	ID string
+	// Name shown in the client, such as "Wukong"
+	Name    string
+	Classes []string
+	Aliases []string
+}
+
+// Data is a Data Dragon bundle of one patch.
+type Data struct {
+	Version   string
+	champions []*Champion
+	byKey     map[int]*Champion
+	byAlias   map[string]*Champion
+	items     map[int]string
+	completed map[int]bool
+	runes     map[int]string
+	spells    map[int]string
+}
+
+// Common names that are not the champion's name.
+var aliases = map[string][]string{
+	"AurelionSol":  {"asol"},
+	"Caitlyn":      {"cait"},
+	"DrMundo":      {"mundo"},
+	"Gangplank":    {"gp"},
+	"Heimerdinger": {"heimer"},
+	"JarvanIV":     {"j4", "jarvan"},
+	"KogMaw":       {"kog"},
+	"Leblanc":      {"lb"},
+	"MasterYi":     {"yi"},
+	"MissFortune":  {"mf"},
+	"MonkeyKing":   {"wu"},
+	"Nunu":         {"nunu"},
+	"TahmKench":    {"tahm"},
+	"TwistedFate":  {"tf"},
+	"Warwick":      {"ww"},
+	"XinZhao":      {"xin"},
+}
+
+// normalize folds a name for comparison, so that "Kai'Sa", "kaisa" and
+// "KAISA" are the same.
+func normalize(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return b.String()
+}
+
+// Load reads a bundle saved by Download.
+func Load(dir string) (*Data, error) {
+	version, err := os.ReadFile(filepath.Join(dir, versionFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no static data in %s (run lolscout static download)", dir)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Data{
+		Version:   strings.TrimSpace(string(version)),
+		byKey:     make(map[int]*Champion),
+		byAlias:   make(map[string]*Champion),
+		items:     make(map[int]string),
+		completed: make(map[int]bool),
+		runes:     make(map[int]string),
+		spells:    make(map[int]string),
+	}
+
+	if err := d.loadChampions(dir); err != nil {
+		return nil, err
+	}
+
+	if err := d.loadItems(dir); err != nil {
+		return nil, err
+	}
+
+	if err := d.loadRunes(dir); err != nil {
+		return nil, err
+	}
+
+	if err := d.loadSpells(dir); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func readJSON(dir, name string, v interface{}) error {
+	contents, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no %s in %s (run lolscout static download)", name, dir)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(contents, v); err != nil {
+		return fmt.Errorf("bad %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (d *Data) loadChampions(dir string) error {
+	var file struct {
+		Data map[string]struct {
+			ID   string   `json:"id"`
+			Key  string   `json:"key"`
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		} `json:"data"`
+	}
+
+	if err := readJSON(dir, championFile, &file); err != nil {
+		return err
+	}
+
+	for _, c := range file.Data {
+		key, err := strconv.Atoi(c.Key)
+		if err != nil {
+			return fmt.Errorf("bad %s: champion %s has key %s", championFile, c.ID, c.Key)
+		}
+
+		champion := &Champion{
+			Key:     key,
+			ID:      c.ID,
+			Name:    c.Name,
+			Classes: c.Tags,
+			Aliases: aliases[c.ID],
+		}
+
+		d.champions = append(d.champions, champion)
+		d.byKey[key] = champion
+	}
+
+	sort.Slice(d.champions, func(i, j int) bool {
+		return d.champions[i].Name < d.champions[j].Name
+	})
+
+	for _, champion := range d.champions {
+		for _, alias := range append([]string{champion.ID, champion.Name}, champion.Aliases...) {
+			d.byAlias[normalize(alias)] = champion
+		}
+	}
+
+	return nil
+}
+
+func (d *Data) loadItems(dir string) error {
+	var file struct {
+		Data map[string]struct {
+			Name  string   `json:"name"`
+			Into  []string `json:"into"`
+			Tags  []string `json:"tags"`
+			Depth int      `json:"depth"`
+		} `json:"data"`
+	}
+
+	if err := readJSON(dir, itemFile, &file); err != nil {
+		return err
+	}
+
+	for id, item := range file.Data {
+		key, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+
+		d.items[key] = item.Name
+
+		// Upgraded boots still build into more boots
+		boots := contains(item.Tags, "Boots")
+		d.completed[key] = item.Depth >= 2 && (len(item.Into) == 0 || boots) && !contains(item.Tags, "Consumable") && !contains(item.Tags, "Trinket")
+	}
+
+	return nil
+}
+
+func (d *Data) loadRunes(dir string) error {
+	type perk struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var trees []struct {
+		perk
+		Slots []struct {
+			Runes []perk `json:"runes"`
+		} `json:"slots"`
+	}
+
+	if err := readJSON(dir, runeFile, &trees); err != nil {
+		return err
+	}
+
+	for _, tree := range trees {
+		d.runes[tree.ID] = tree.Name
+
+		for _, slot := range tree.Slots {
+			for _, r := range slot.Runes {
+				d.runes[r.ID] = r.Name
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *Data) loadSpells(dir string) error {
+	var file struct {
+		Data map[string]struct {
+			Key  string `json:"key"`
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+
+	if err := readJSON(dir, spellFile, &file); err != nil {
+		return err
+	}
+
+	for _, spell := range file.Data {
+		key, err := strconv.Atoi(spell.Key)
+		if err != nil {
+			continue
+		}
+
+		d.spells[key] = spell.Name
+	}
+
+	return nil
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Data) Champions() []*Champion {
+	return d.champions
+}
+
+func (d *Data) ChampionByKey(key int) (*Champion, bool) {
+	champion, ok := d.byKey[key]
+	return champion, ok
+}
+
+// Champion finds a champion by its ID, name, alias or the start of one of
+// them, ignoring case and punctuation.
+func (d *Data) Champion(s string) (*Champion, error) {
+	name := normalize(s)
+
+	if champion, ok := d.byAlias[name]; ok {
+		return champion, nil
+	}
+
+	var matches []*Champion
+
+	for _, champion := range d.champions {
+		if strings.HasPrefix(normalize(champion.ID), name) || strings.HasPrefix(normalize(champion.Name), name) {
+			matches = append(matches, champion)
+		}
+	}
+
+	if len(name) > 0 && len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	if len(name) > 0 && len(matches) > 1 {
+		names := make([]string, len(matches))
+
+		for i, match := range matches {
+			names[i] = match.Name
+		}
+
+		return nil, fmt.Errorf("ambiguous champion %s: could be %s", s, strings.Join(names, ", "))
+	}
+
+	return nil, fmt.Errorf("unknown champion %s", s)
+}
+
+// ChampionNames maps champion keys to the names used by match-v5.
+func (d *Data) ChampionNames() map[int]string {
+	names := make(map[int]string)
+
+	for key, champion := range d.byKey {
+		names[key] = champion.ID
+	}
+
+	return names
+}
+
+// Item is the name of an item, or its ID if it is unknown.
+func (d *Data) Item(id int) string {
+	if name, ok := d.items[id]; ok {
+		return name
+	}
+
+	return fmt.Sprint(id)
+}
+
+// Completed reports whether an item is finished rather than a starting
+// item, component or consumable.
+func (d *Data) Completed(id int) bool {
+	return d.completed[id]
+}
+
+// SummonerSpell is the name of a summoner spell, or its ID if it is unknown.
+func (d *Data) SummonerSpell(id int) string {
+	if name, ok := d.spells[id]; ok {
+		return name
+	}
+
+	return fmt.Sprint(id)
+}
+
+// Rune is the name of a rune or rune tree, or its ID if it is unknown.
+func (d *Data) Rune(id int) string {
+	if name, ok := d.runes[id]; ok {
+		return name
+	}
+
+	return fmt.Sprint(id)
+}
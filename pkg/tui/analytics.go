@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/charmbracelet/lipgloss"
@@ -50,7 +51,7 @@ func ViewAnalytics(title string, columns []string, analytics []*analytics.Analyt
 		for j, a := range analytics {
 			s1 := reflect.ValueOf(a).Elem()
 
-			rowValues[j+1] = fmt.Sprintf("%.2f", s1.Field(i).Float())
+			rowValues[j+1] = formatValue("%.2f", s1.Field(i).Float())
 		}
 
 		t.Row(rowValues...)
@@ -58,3 +59,13 @@ func ViewAnalytics(title string, columns []string, analytics []*analytics.Analyt
 
 	fmt.Println(t.String())
 }
+
+// formatValue formats an analytics value, or shows "-" for a metric with no
+// data.
+func formatValue(format string, value float64) string {
+	if math.IsNaN(value) {
+		return "-"
+	}
+
+	return fmt.Sprintf(format, value)
+}
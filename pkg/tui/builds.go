@@ -0,0 +1,23 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/haydenheroux/lolscout/pkg/analytics"
+)
+
+func ViewChoices(title string, choices []*analytics.Choice) {
+	if len(choices) == 0 {
+		return
+	}
+
+	t := createTable()
+
+	t.Headers(title, "Games", "Win Rate")
+
+	for _, choice := range choices {
+		t.Row(choice.Name, fmt.Sprint(choice.Games), fmt.Sprintf("%.0f%%", choice.WinRate()*100))
+	}
+
+	fmt.Println(t.String())
+}
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/haydenheroux/lolscout/pkg/analytics"
+	"github.com/haydenheroux/lolscout/pkg/model"
+)
+
+// LiveParticipant is a player in a game in progress.
+type LiveParticipant struct {
+	RiotID   string
+	Champion model.Champion
+	Rank     string
+	// Nil if there are no stored matches of the player on the champion
+	Analytics *analytics.Analytics
+}
+
+func ViewLiveTeam(title string, participants []LiveParticipant) {
+	if len(participants) == 0 {
+		return
+	}
+
+	t := createTable()
+
+	t.Headers(append([]string{title, "Champion", "Rank"}, performanceHeaders...)...)
+
+	for _, participant := range participants {
+		t.Row(append([]string{participant.RiotID, participant.Champion.String(), participant.Rank}, performance(participant.Analytics)...)...)
+	}
+
+	fmt.Println(t.String())
+}
@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haydenheroux/lolscout/pkg/model"
+)
+
+func ViewMatchTeam(title string, participants []model.Participant, bans []string) {
+	if len(participants) == 0 {
+		return
+	}
+
+	t := createTable()
+
+	t.Headers(title, "Champion", "Position", "KDA")
+
+	for _, participant := range participants {
+		riotId := fmt.Sprintf("%s#%s", participant.GameName, participant.TagLine)
+
+		if participant.GameName == "" {
+			riotId = "-"
+		}
+
+		t.Row(riotId, participant.Champion.String(), participant.Position.String(), fmt.Sprintf("%d/%d/%d", participant.Kills, participant.Deaths, participant.Assists))
+	}
+
+	fmt.Println(t.String())
+
+	if len(bans) > 0 {
+		fmt.Printf("Bans: %s\n", strings.Join(bans, ", "))
+	}
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/haydenheroux/lolscout/pkg/analytics"
+	"github.com/haydenheroux/lolscout/pkg/model"
+)
+
+// ViewPatches shows performance on each patch, oldest first.
+func ViewPatches(title string, byPatch analytics.AnalyticsByPatch) {
+	if len(byPatch) == 0 {
+		return
+	}
+
+	patches := make([]string, 0, len(byPatch))
+
+	for patch := range byPatch {
+		patches = append(patches, patch)
+	}
+
+	sort.Slice(patches, func(i, j int) bool {
+		return model.ComparePatches(patches[i], patches[j]) < 0
+	})
+
+	t := createTable()
+
+	t.Headers(append([]string{title}, append(performanceHeaders, "DMG/m", "DMG%")...)...)
+
+	for _, patch := range patches {
+		a := byPatch[patch]
+		mean := a.Mean()
+
+		row := append([]string{patch}, performance(a)...)
+		row = append(row, formatValue("%.0f", mean.DamageDealtPerMinute), formatValue("%.0f%%", mean.DamageDealtShare*100))
+
+		t.Row(row...)
+	}
+
+	fmt.Println(t.String())
+}
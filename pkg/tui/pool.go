@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/haydenheroux/lolscout/pkg/analytics"
+)
+
+func ViewPool(title string, pool []*analytics.PoolEntry) {
+	if len(pool) == 0 {
+		return
+	}
+
+	t := createTable()
+
+	t.Headers(append([]string{title, "Mastery", "Last Played"}, performanceHeaders...)...)
+
+	for _, entry := range pool {
+		mastery, lastPlayed := "-", "-"
+
+		if entry.Mastery != nil {
+			mastery = fmt.Sprintf("%d (%s)", entry.Mastery.Level, points(entry.Mastery.Points))
+			lastPlayed = entry.Mastery.LastPlayTime.Format("2006-01-02")
+		}
+
+		t.Row(append([]string{entry.Champion.String(), mastery, lastPlayed}, performance(entry.Analytics)...)...)
+	}
+
+	fmt.Println(t.String())
+}
+
+var performanceHeaders = []string{"Games", "Win Rate", "KDA", "CS/m"}
+
+// performance summarizes analytics in the columns of performanceHeaders.
+func performance(a *analytics.Analytics) []string {
+	if a == nil {
+		return []string{"0", "-", "-", "-"}
+	}
+
+	mean := a.Mean()
+
+	kda := "-"
+
+	if !math.IsNaN(mean.Kills) {
+		kda = fmt.Sprintf("%.1f/%.1f/%.1f", mean.Kills, mean.Deaths, mean.Assists)
+	}
+
+	return []string{
+		fmt.Sprint(a.Size),
+		formatValue("%.0f%%", mean.WinRate*100),
+		kda,
+		formatValue("%.1f", mean.CSPerMinute),
+	}
+}
+
+// points abbreviates mastery points, such as "312k".
+func points(points int) string {
+	if points < 1000 {
+		return fmt.Sprint(points)
+	}
+
+	return fmt.Sprintf("%dk", points/1000)
+}
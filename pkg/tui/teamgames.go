@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	lolApi "github.com/haydenheroux/lolscout/pkg/api/lol"
+	"github.com/haydenheroux/lolscout/pkg/model"
+)
+
+var sideNames = map[int]string{
+	100: "Blue",
+	200: "Red",
+}
+
+func ViewTeamGames(title string, games []*model.TeamGame, names map[string]string) {
+	if len(games) == 0 {
+		return
+	}
+
+	// Wider than other tables, since it lists the players
+	t := createTable().Width(0)
+
+	t.Headers(title, "Date", "Queue", "Side", "Players", "Result")
+
+	for _, game := range games {
+		var players []string
+
+		for _, puuid := range game.MemberPUUIDs() {
+			players = append(players, names[puuid])
+		}
+
+		side := sideNames[game.Side]
+
+		if game.OpponentSide != 0 {
+			side = fmt.Sprintf("%s vs %s", side, sideNames[game.OpponentSide])
+		}
+
+		result := "Loss"
+
+		if game.Win {
+			result = "Win"
+		}
+
+		t.Row(game.MatchID, game.Match.StartTime.Format("2006-01-02"), lolApi.QueueType(game.Match.QueueID).String(), side, strings.Join(players, ", "), result)
+	}
+
+	fmt.Println(t.String())
+}